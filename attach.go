@@ -0,0 +1,33 @@
+package pdf
+
+import "fmt"
+
+// AttachFile embeds a file in the document, with the given name and
+// contents. Viewers list attached files in an attachments panel, from which
+// they can be saved. The name may contain any Unicode characters; it is
+// stored both in PDFDocEncoding (for older viewers) and in UTF-16. If a file
+// with the same name was already attached, it is replaced.
+func (d *Document) AttachFile(name string, data []byte) {
+	s := new(stream)
+	s.b.Write(data)
+	s.extraData = fmt.Sprintf("/Type /EmbeddedFile /Params << /Size %d >>", len(data))
+	if d.attachments == nil {
+		d.attachments = make(map[string]*stream)
+	}
+	d.attachments[name] = s
+}
+
+// embeddedFilesTree returns the name tree of the document's attached files,
+// or "" if there are none.
+func (d *Document) embeddedFilesTree(e *encoder) string {
+	if len(d.attachments) == 0 {
+		return ""
+	}
+	names := make([]string, 0, len(d.attachments))
+	for name := range d.attachments {
+		names = append(names, name)
+	}
+	return nameTree(names, func(name string) string {
+		return fmt.Sprintf("<< /Type /Filespec /F %s /UF %s /EF << /F %d 0 R >> >>", pdfDocString(name), textString(name), e.getRef(d.attachments[name]))
+	})
+}
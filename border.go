@@ -0,0 +1,89 @@
+package pdf
+
+// EdgeFlags selects which edges of a rectangle to draw with Border.
+type EdgeFlags int
+
+const (
+	EdgeTop EdgeFlags = 1 << iota
+	EdgeRight
+	EdgeBottom
+	EdgeLeft
+
+	AllEdges = EdgeTop | EdgeRight | EdgeBottom | EdgeLeft
+)
+
+// A BorderStyle specifies how Border draws lines.
+type BorderStyle struct {
+	Width float64
+
+	// Color is the color of the lines. If it is nil, the current stroke
+	// color is used.
+	Color Color
+
+	// Dash is the dash pattern, as for SetDash. If it is empty, the lines
+	// are solid, even if a dash pattern is currently set.
+	Dash []float64
+}
+
+// Border strokes the selected edges of the rectangle with its lower left
+// corner at (x, y). The graphics state is saved and restored, so the style
+// does not affect subsequent drawing.
+//
+// Adjacent edges are drawn as a single path, so they meet in a mitered
+// corner, and the dash pattern continues around the corner. The ends of a
+// run of edges (where an edge's neighbor is not selected) have butt caps: the
+// line stops exactly at the corner, without extending past it.
+func (p *Page) Border(x, y, width, height float64, edges EdgeFlags, style BorderStyle) {
+	edges &= AllEdges
+	if edges == 0 {
+		return
+	}
+
+	p.Save()
+	p.SetLineWidth(style.Width)
+	if style.Color != nil {
+		p.SetStrokeColor(style.Color)
+	}
+	// The caps, joins, and dash pattern are set explicitly, since the
+	// graphics state may already have others from content drawn earlier.
+	p.contents.num(0)
+	p.contents.op("J")
+	p.contents.num(0)
+	p.contents.op("j")
+	p.SetDash(style.Dash, 0)
+
+	if edges == AllEdges {
+		p.Rectangle(x, y, width, height)
+	} else {
+		// corners[i] is the starting point of side i, going clockwise
+		// from the top left corner.
+		corners := [4][2]float64{{x, y + height}, {x + width, y + height}, {x + width, y}, {x, y}}
+		sides := [4]EdgeFlags{EdgeTop, EdgeRight, EdgeBottom, EdgeLeft}
+
+		// Start at a selected side whose predecessor isn't selected, so
+		// that each run of adjacent sides is drawn as one subpath.
+		start := 0
+		for i := range sides {
+			if edges&sides[i] != 0 && edges&sides[(i+3)%4] == 0 {
+				start = i
+				break
+			}
+		}
+		drawing := false
+		for k := 0; k < 4; k++ {
+			i := (start + k) % 4
+			if edges&sides[i] == 0 {
+				drawing = false
+				continue
+			}
+			if !drawing {
+				p.MoveTo(corners[i][0], corners[i][1])
+				drawing = true
+			}
+			end := corners[(i+1)%4]
+			p.LineTo(end[0], end[1])
+		}
+	}
+	p.Stroke()
+	p.Restore()
+}
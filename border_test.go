@@ -0,0 +1,22 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestBorderResetsLineStyle(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetDash([]float64{3, 2}, 0)
+	p.Border(72, 72, 100, 50, EdgeTop|EdgeLeft, BorderStyle{Width: 2})
+	content := prettyContent(p.pageContent())
+	for _, want := range []string{"0 J", "0 j", "[] 0 d"} {
+		if !strings.Contains(string(content), want) {
+			t.Errorf("border content doesn't set %q:\n%s", want, content)
+		}
+	}
+	if p.state.dash == nil || len(p.state.dash) != 2 {
+		t.Errorf("dash pattern not restored after Border: %v", p.state.dash)
+	}
+}
@@ -0,0 +1,188 @@
+package pdf
+
+// This file implements CCITT Group 4 (ITU-T T.6) compression, for bilevel
+// images.
+
+// Group 4 mode codes.
+const (
+	g4Pass       = "0001"
+	g4Horizontal = "001"
+)
+
+// g4Vertical holds the codes for vertical mode, indexed by a1 - b1 + 3.
+var g4Vertical = [7]string{"0000010", "000010", "010", "1", "011", "000011", "0000011"}
+
+// Terminating codes for run lengths from 0 to 63.
+var (
+	whiteTerminating = [64]string{
+		"00110101", "000111", "0111", "1000", "1011", "1100", "1110", "1111",
+		"10011", "10100", "00111", "01000", "001000", "000011", "110100", "110101",
+		"101010", "101011", "0100111", "0001100", "0001000", "0010111", "0000011", "0000100",
+		"0101000", "0101011", "0010011", "0100100", "0011000", "00000010", "00000011", "00011010",
+		"00011011", "00010010", "00010011", "00010100", "00010101", "00010110", "00010111", "00101000",
+		"00101001", "00101010", "00101011", "00101100", "00101101", "00000100", "00000101", "00001010",
+		"00001011", "01010010", "01010011", "01010100", "01010101", "00100100", "00100101", "01011000",
+		"01011001", "01011010", "01011011", "01001010", "01001011", "00110010", "00110011", "00110100",
+	}
+	blackTerminating = [64]string{
+		"0000110111", "010", "11", "10", "011", "0011", "0010", "00011",
+		"000101", "000100", "0000100", "0000101", "0000111", "00000100", "00000111", "000011000",
+		"0000010111", "0000011000", "0000001000", "00001100111", "00001101000", "00001101100", "00000110111", "00000101000",
+		"00000010111", "00000011000", "000011001010", "000011001011", "000011001100", "000011001101", "000001101000", "000001101001",
+		"000001101010", "000001101011", "000011010010", "000011010011", "000011010100", "000011010101", "000011010110", "000011010111",
+		"000001101100", "000001101101", "000011011010", "000011011011", "000001010100", "000001010101", "000001010110", "000001010111",
+		"000001100100", "000001100101", "000001010010", "000001010011", "000000100100", "000000110111", "000000111000", "000000100111",
+		"000000101000", "000001011000", "000001011001", "000000101011", "000000101100", "000001011010", "000001100110", "000001100111",
+	}
+)
+
+// Makeup codes for run lengths from 64 to 1728, in multiples of 64.
+var (
+	whiteMakeup = [27]string{
+		"11011", "10010", "010111", "0110111", "00110110", "00110111", "01100100", "01100101",
+		"01101000", "01100111", "011001100", "011001101", "011010010", "011010011", "011010100", "011010101",
+		"011010110", "011010111", "011011000", "011011001", "011011010", "011011011", "010011000", "010011001",
+		"010011010", "011000", "010011011",
+	}
+	blackMakeup = [27]string{
+		"0000001111", "000011001000", "000011001001", "000001011011", "000000110011", "000000110100", "000000110101", "0000001101100",
+		"0000001101101", "0000001001010", "0000001001011", "0000001001100", "0000001001101", "0000001110010", "0000001110011", "0000001110100",
+		"0000001110101", "0000001110110", "0000001110111", "0000001010010", "0000001010011", "0000001010100", "0000001010101", "0000001011010",
+		"0000001011011", "0000001100100", "0000001100101",
+	}
+)
+
+// extendedMakeup holds the makeup codes (shared by black and white runs) for
+// run lengths from 1792 to 2560, in multiples of 64.
+var extendedMakeup = [13]string{
+	"00000001000", "00000001100", "00000001101", "000000010010", "000000010011", "000000010100", "000000010101",
+	"000000010110", "000000010111", "000000011100", "000000011101", "000000011110", "000000011111",
+}
+
+// g4EOL is the end-of-line code; two of them make up the end-of-block code
+// that ends a Group 4 image.
+const g4EOL = "000000000001"
+
+// A bitWriter accumulates codes written as strings of '0' and '1'.
+type bitWriter struct {
+	buf   []byte
+	cur   byte
+	nBits uint
+}
+
+func (w *bitWriter) writeCode(code string) {
+	for i := 0; i < len(code); i++ {
+		w.cur <<= 1
+		if code[i] == '1' {
+			w.cur |= 1
+		}
+		w.nBits++
+		if w.nBits == 8 {
+			w.buf = append(w.buf, w.cur)
+			w.cur, w.nBits = 0, 0
+		}
+	}
+}
+
+// bytes returns the data written, padded with zero bits to a byte boundary.
+func (w *bitWriter) bytes() []byte {
+	if w.nBits > 0 {
+		return append(w.buf, w.cur<<(8-w.nBits))
+	}
+	return w.buf
+}
+
+// writeRun writes the codes for a run of n pixels of one color.
+func (w *bitWriter) writeRun(n int, black bool) {
+	terminating, makeup := &whiteTerminating, &whiteMakeup
+	if black {
+		terminating, makeup = &blackTerminating, &blackMakeup
+	}
+	for n >= 2560 {
+		w.writeCode(extendedMakeup[len(extendedMakeup)-1])
+		n -= 2560
+	}
+	if n >= 64 {
+		m := n / 64
+		if m <= len(makeup) {
+			w.writeCode(makeup[m-1])
+		} else {
+			w.writeCode(extendedMakeup[m-len(makeup)-1])
+		}
+	}
+	w.writeCode(terminating[n%64])
+}
+
+// encodeG4 compresses a bilevel image with CCITT Group 4 encoding. Each row
+// of the image is packed 8 pixels per byte, most significant bit first,
+// starting on a byte boundary, with 1 for white and 0 for black.
+func encodeG4(data []byte, width, height int) []byte {
+	rowBytes := (width + 7) / 8
+	w := new(bitWriter)
+	// The reference line for the first row is an imaginary white line.
+	ref := make([]byte, rowBytes)
+	for i := range ref {
+		ref[i] = 0xff
+	}
+
+	for y := 0; y < height; y++ {
+		cur := data[y*rowBytes : (y+1)*rowBytes]
+		// black reports whether pixel i of line is black. The pixel before
+		// the start of the line is considered white.
+		black := func(line []byte, i int) bool {
+			if i < 0 {
+				return false
+			}
+			return line[i/8]&(0x80>>uint(i%8)) == 0
+		}
+		// next returns the position of the first pixel at or after start
+		// that is the color specified, or width if there isn't one.
+		next := func(line []byte, start int, color bool) int {
+			for i := start; i < width; i++ {
+				if black(line, i) == color {
+					return i
+				}
+			}
+			return width
+		}
+
+		a0 := -1
+		color := false // the color of a0, initially white
+		for a0 < width {
+			// Pixel a0 (if it is on the line) is always the current color,
+			// so a1 is the first pixel after it that isn't.
+			a1 := next(cur, a0+1, !color)
+			// b1 is the first changing element on the reference line to
+			// the right of a0 that changes to the opposite of color.
+			b1 := a0 + 1
+			for b1 < width && !(black(ref, b1) != color && black(ref, b1-1) == color) {
+				b1++
+			}
+			b2 := next(ref, b1, color)
+
+			switch {
+			case b2 < a1:
+				w.writeCode(g4Pass)
+				a0 = b2
+			case a1-b1 >= -3 && a1-b1 <= 3:
+				w.writeCode(g4Vertical[a1-b1+3])
+				a0 = a1
+				color = !color
+			default:
+				a2 := next(cur, a1, color)
+				w.writeCode(g4Horizontal)
+				if a0 < 0 {
+					a0 = 0
+				}
+				w.writeRun(a1-a0, color)
+				w.writeRun(a2-a1, !color)
+				a0 = a2
+			}
+		}
+		ref = cur
+	}
+
+	w.writeCode(g4EOL)
+	w.writeCode(g4EOL)
+	return w.bytes()
+}
@@ -0,0 +1,192 @@
+package pdf
+
+import "math"
+
+// valueRange returns the smallest and largest of values.
+func valueRange(values []float64) (min, max float64) {
+	min, max = values[0], values[0]
+	for _, v := range values[1:] {
+		if v < min {
+			min = v
+		}
+		if v > max {
+			max = v
+		}
+	}
+	return min, max
+}
+
+// sparklinePoints returns the positions of values in a sparkline drawn in
+// the box with its lower left corner at (x, y).
+func sparklinePoints(x, y, width, height float64, values []float64) [][2]float64 {
+	min, max := valueRange(values)
+	points := make([][2]float64, len(values))
+	for i, v := range values {
+		px := x
+		if len(values) > 1 {
+			px += width * float64(i) / float64(len(values)-1)
+		}
+		py := y + height/2
+		if max > min {
+			py = y + height*(v-min)/(max-min)
+		}
+		points[i] = [2]float64{px, py}
+	}
+	return points
+}
+
+// Sparkline strokes a line chart of values, scaled to fill the box with its
+// lower left corner at (x, y): the values are spaced evenly from left to
+// right, and the smallest value is at the bottom of the box and the largest
+// at the top.
+func (p *Page) Sparkline(x, y, width, height float64, values []float64) {
+	if len(values) < 2 {
+		return
+	}
+	for i, pt := range sparklinePoints(x, y, width, height, values) {
+		if i == 0 {
+			p.MoveTo(pt[0], pt[1])
+		} else {
+			p.LineTo(pt[0], pt[1])
+		}
+	}
+	p.Stroke()
+}
+
+// SparklineMarkers fills dots of the given radius at the minimum and maximum
+// points of a sparkline drawn with the same arguments.
+func (p *Page) SparklineMarkers(x, y, width, height float64, values []float64, radius float64) {
+	if len(values) == 0 {
+		return
+	}
+	points := sparklinePoints(x, y, width, height, values)
+	minIndex, maxIndex := 0, 0
+	for i, v := range values {
+		if v < values[minIndex] {
+			minIndex = i
+		}
+		if v > values[maxIndex] {
+			maxIndex = i
+		}
+	}
+	p.circle(points[minIndex][0], points[minIndex][1], radius)
+	if maxIndex != minIndex {
+		p.circle(points[maxIndex][0], points[maxIndex][1], radius)
+	}
+	p.Fill()
+}
+
+// circle adds a circle to the current path, approximated with four Bézier
+// curves.
+func (p *Page) circle(cx, cy, r float64) {
+	// k is the distance of the control points from the ends of each curve.
+	const k = 0.5522847498
+	p.MoveTo(cx+r, cy)
+	p.CurveTo(cx+r, cy+k*r, cx+k*r, cy+r, cx, cy+r)
+	p.CurveTo(cx-k*r, cy+r, cx-r, cy+k*r, cx-r, cy)
+	p.CurveTo(cx-r, cy-k*r, cx-k*r, cy-r, cx, cy-r)
+	p.CurveTo(cx+k*r, cy-r, cx+r, cy-k*r, cx+r, cy)
+	p.ClosePath()
+}
+
+// BarChart fills a bar chart of values in the box with its lower left corner
+// at (x, y). The bars are evenly spaced, with gaps between them, and their
+// heights are proportional to the values. If any values are negative, the
+// baseline is raised so that their bars extend downward from it.
+func (p *Page) BarChart(x, y, width, height float64, values []float64) {
+	if len(values) == 0 {
+		return
+	}
+	min, max := valueRange(values)
+	if min > 0 {
+		min = 0
+	}
+	if max < 0 {
+		max = 0
+	}
+	if max == min {
+		return
+	}
+
+	scale := height / (max - min)
+	baseline := y - min*scale
+	slot := width / float64(len(values))
+	gap := slot * 0.2
+	for i, v := range values {
+		barX := x + float64(i)*slot + gap/2
+		if v >= 0 {
+			p.Rectangle(barX, baseline, slot-gap, v*scale)
+		} else {
+			p.Rectangle(barX, baseline+v*scale, slot-gap, -v*scale)
+		}
+	}
+	p.Fill()
+}
+
+// arc adds a circular arc to the current path, centered at (cx, cy), from
+// angle a0 to angle a1 (in radians, counterclockwise from the positive x
+// axis, or clockwise if a1 < a0). The current point should already be at
+// the start of the arc. The arc is approximated with one Bézier curve for
+// each quarter circle or part of one.
+func (p *Page) arc(cx, cy, r, a0, a1 float64) {
+	n := math.Ceil(math.Abs(a1-a0) / (math.Pi / 2))
+	step := (a1 - a0) / n
+	k := 4.0 / 3 * math.Tan(step/4) * r
+	for i := 0; i < int(n); i++ {
+		a := a0 + float64(i)*step
+		b := a + step
+		x0, y0 := cx+r*math.Cos(a), cy+r*math.Sin(a)
+		x3, y3 := cx+r*math.Cos(b), cy+r*math.Sin(b)
+		p.CurveTo(x0-k*math.Sin(a), y0+k*math.Cos(a), x3+k*math.Sin(b), y3-k*math.Cos(b), x3, y3)
+	}
+}
+
+// PieChart fills a pie chart of values, centered at (cx, cy) with radius r.
+// The slices start at the top and go clockwise, each with an angle
+// proportional to its value, and filled with the corresponding color from
+// colors (which is reused from the beginning if there are more values than
+// colors). Negative values are treated as zero. If the values add up to
+// zero, nothing is drawn. The fill color is restored afterward.
+func (p *Page) PieChart(cx, cy, r float64, values []float64, colors []Color) {
+	p.DonutChart(cx, cy, r, 0, values, colors)
+}
+
+// DonutChart is like PieChart, but it leaves a hole of radius innerR in the
+// center.
+func (p *Page) DonutChart(cx, cy, r, innerR float64, values []float64, colors []Color) {
+	total := 0.0
+	for _, v := range values {
+		if v > 0 {
+			total += v
+		}
+	}
+	if total == 0 {
+		return
+	}
+
+	p.Save()
+	angle := math.Pi / 2
+	for i, v := range values {
+		if v <= 0 {
+			continue
+		}
+		end := angle - v/total*2*math.Pi
+		if len(colors) > 0 {
+			p.SetFillColor(colors[i%len(colors)])
+		}
+		if innerR > 0 {
+			p.MoveTo(cx+r*math.Cos(angle), cy+r*math.Sin(angle))
+			p.arc(cx, cy, r, angle, end)
+			p.LineTo(cx+innerR*math.Cos(end), cy+innerR*math.Sin(end))
+			p.arc(cx, cy, innerR, end, angle)
+		} else {
+			p.MoveTo(cx, cy)
+			p.LineTo(cx+r*math.Cos(angle), cy+r*math.Sin(angle))
+			p.arc(cx, cy, r, angle, end)
+		}
+		p.ClosePath()
+		p.Fill()
+		angle = end
+	}
+	p.Restore()
+}
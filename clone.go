@@ -0,0 +1,207 @@
+package pdf
+
+// Clone returns a copy of d, which can be modified without affecting d, for
+// building variations on a base document.
+//
+// The pages are copied, with their content streams, resources, and
+// structure elements, as are the document's settings, named destinations,
+// and attached files. Fonts, images, color spaces, patterns, templates,
+// and layers are shared, since they are not changed by drawing with them
+// (except that characters used in one document are added to the encoding
+// of a shared font, which makes no visible difference). Headers and footers set with
+// SetHeader and SetFooter are shared too, since they are functions. The
+// first part of the generated file identifier (see SetID) is not copied, so
+// the clone gets its own when it is first encoded.
+func (d *Document) Clone() *Document {
+	c := new(Document)
+	*c = *d
+	c.permanentID = nil
+
+	c.fontCache = make(map[string]*Font, len(d.fontCache))
+	for k, v := range d.fontCache {
+		c.fontCache[k] = v
+	}
+	c.imageCache = make(map[string]*Image, len(d.imageCache))
+	for k, v := range d.imageCache {
+		c.imageCache[k] = v
+	}
+	c.layers = append([]*Layer(nil), d.layers...)
+	c.rawObjects = append([]*rawObject(nil), d.rawObjects...)
+	c.extGStates = make(map[string]*extGState, len(d.extGStates))
+	for k, v := range d.extGStates {
+		c.extGStates[k] = v
+	}
+	c.attachments = make(map[string]*stream, len(d.attachments))
+	for k, v := range d.attachments {
+		c.attachments[k] = v.clone()
+	}
+	if d.docMDP != nil {
+		sig := *d.docMDP
+		c.docMDP = &sig
+	}
+
+	pages := make(map[*Page]*Page, len(d.pages.pages))
+	elems := make(map[*structElem]*structElem)
+	c.pages = pageTree{pages: make([]*Page, len(d.pages.pages))}
+	c.structTree = structTreeRoot{}
+	if d.structTree.doc != nil {
+		c.structTree.doc = c
+	}
+	for i, p := range d.pages.pages {
+		np := p.clone(c)
+		for j, elem := range p.structElems {
+			ne := &structElem{
+				root:  &c.structTree,
+				tag:   elem.tag,
+				page:  np,
+				mcid:  elem.mcid,
+				order: elem.order,
+			}
+			np.structElems[j] = ne
+			elems[elem] = ne
+		}
+		c.pages.pages[i] = np
+		pages[p] = np
+	}
+	c.structTree.elems = cloneStructElems(d.structTree.elems, &c.structTree, nil, elems, pages)
+
+	c.dests = make(map[string]Destination, len(d.dests))
+	for k, v := range d.dests {
+		v.Page = pages[v.Page]
+		c.dests[k] = v
+	}
+	if d.openAction != nil {
+		a := *d.openAction
+		a.Page = pages[a.Page]
+		c.openAction = &a
+	}
+	c.outline.children = cloneBookmarks(d.outline.children, pages)
+	for _, np := range c.pages.pages {
+		annots := np.annots
+		np.annots = make([]*linkAnnot, len(annots))
+		for i, a := range annots {
+			na := *a
+			na.dest.Page = pages[a.dest.Page]
+			np.annots[i] = &na
+		}
+	}
+	return c
+}
+
+// cloneStructElems returns a copy of the structure elements in list, as
+// children of parent. Elements with marked content have already been
+// copied, and are looked up in leaves; pages maps the original pages to
+// the copies.
+func cloneStructElems(list []*structElem, root *structTreeRoot, parent *structElem, leaves map[*structElem]*structElem, pages map[*Page]*Page) []*structElem {
+	result := make([]*structElem, len(list))
+	for i, elem := range list {
+		ne := leaves[elem]
+		if elem.group {
+			ne = &structElem{root: root, tag: elem.tag, attrs: elem.attrs, page: pages[elem.page], order: elem.order, group: true}
+			ne.kids = cloneStructElems(elem.kids, root, ne, leaves, pages)
+		}
+		ne.parent = parent
+		result[i] = ne
+	}
+	return result
+}
+
+// cloneBookmarks returns a copy of list, with the destinations moved to the
+// corresponding pages in pages.
+func cloneBookmarks(list []*Bookmark, pages map[*Page]*Page) []*Bookmark {
+	if list == nil {
+		return nil
+	}
+	result := make([]*Bookmark, len(list))
+	for i, b := range list {
+		nb := &Bookmark{title: b.title, dest: b.dest, expanded: b.expanded}
+		nb.dest.Page = pages[b.dest.Page]
+		nb.children = cloneBookmarks(b.children, pages)
+		result[i] = nb
+	}
+	return result
+}
+
+// clone returns a copy of p, as a page of the document c.
+func (p *Page) clone(c *Document) *Page {
+	np := new(Page)
+	*np = *p
+	np.doc = c
+	np.parent = &c.pages
+	np.decorations = nil
+	np.background = nil
+	np.sigField = nil
+	np.outlineText = nil
+	np.pathStream = nil
+	np.hookThumb, np.hookErr = nil, nil
+
+	np.fragments = make([]*stream, len(p.fragments))
+	for i, f := range p.fragments {
+		np.fragments[i] = f.clone()
+		if f == p.contents {
+			np.contents = np.fragments[i]
+		}
+	}
+
+	np.boxes = make(map[string]Rect, len(p.boxes))
+	for k, v := range p.boxes {
+		np.boxes[k] = v
+	}
+	np.fonts = make(map[*Font]int, len(p.fonts))
+	for k, v := range p.fonts {
+		np.fonts[k] = v
+	}
+	np.images = make(map[*Image]int, len(p.images))
+	for k, v := range p.images {
+		np.images[k] = v
+	}
+	np.colorSpaces = make(map[*ColorSpace]int, len(p.colorSpaces))
+	for k, v := range p.colorSpaces {
+		np.colorSpaces[k] = v
+	}
+	np.extGStates = make(map[*extGState]bool, len(p.extGStates))
+	for k, v := range p.extGStates {
+		np.extGStates[k] = v
+	}
+	np.patterns = make(map[*Pattern]int, len(p.patterns))
+	for k, v := range p.patterns {
+		np.patterns[k] = v
+	}
+	np.shadings = make(map[*Gradient]int, len(p.shadings))
+	for k, v := range p.shadings {
+		np.shadings[k] = v
+	}
+	np.templates = make(map[*Template]int, len(p.templates))
+	for k, v := range p.templates {
+		np.templates[k] = v
+	}
+
+	np.layers = make(map[*Layer]int, len(p.layers))
+	for k, v := range p.layers {
+		np.layers[k] = v
+	}
+
+	np.rawResources = make(map[string][]Ref, len(p.rawResources))
+	for k, v := range p.rawResources {
+		np.rawResources[k] = append([]Ref(nil), v...)
+	}
+
+	np.structElems = make([]*structElem, len(p.structElems))
+	np.redactions = append([]Rect(nil), p.redactions...)
+	np.stamps = append([]*stampAnnot(nil), p.stamps...)
+	np.stateStack = append([]savedState(nil), p.stateStack...)
+	np.problems = append([]string(nil), p.problems...)
+	return np
+}
+
+// clone returns a copy of s, with its own buffer.
+func (s *stream) clone() *stream {
+	ns := &stream{
+		extraData:   s.extraData,
+		filter:      s.filter,
+		decodeParms: s.decodeParms,
+		content:     s.content,
+	}
+	ns.b.Write(s.b.Bytes())
+	return ns
+}
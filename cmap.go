@@ -0,0 +1,90 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"unicode/utf16"
+)
+
+// toUnicodeCMap returns a ToUnicode CMap stream mapping single-byte
+// character codes to the text they represent. The text for a code may be
+// more than one character (for a ligature, for example); it is written as a
+// UTF-16BE string, so characters outside the Basic Multilingual Plane are
+// represented as surrogate pairs.
+func toUnicodeCMap(text map[byte]string) *stream {
+	codes := make([]int, 0, len(text))
+	for c := range text {
+		codes = append(codes, int(c))
+	}
+	sort.Ints(codes)
+
+	s := new(stream)
+	b := &s.b
+	b.WriteString("/CIDInit /ProcSet findresource begin\n")
+	b.WriteString("12 dict begin\n")
+	b.WriteString("begincmap\n")
+	b.WriteString("/CIDSystemInfo << /Registry (Adobe) /Ordering (UCS) /Supplement 0 >> def\n")
+	b.WriteString("/CMapName /Adobe-Identity-UCS def\n")
+	b.WriteString("/CMapType 2 def\n")
+	b.WriteString("1 begincodespacerange\n<00> <FF>\nendcodespacerange\n")
+
+	// Runs of codes that map to consecutive characters are written as
+	// bfrange entries, and the rest as bfchar entries.
+	var singles, ranges []string
+	for i := 0; i < len(codes); {
+		j := i + 1
+		for j < len(codes) && codes[j] == codes[j-1]+1 && consecutive(text[byte(codes[j-1])], text[byte(codes[j])]) {
+			j++
+		}
+		if j-i > 1 {
+			ranges = append(ranges, fmt.Sprintf("<%02X> <%02X> <%s>", codes[i], codes[j-1], utf16Hex(text[byte(codes[i])])))
+		} else {
+			singles = append(singles, fmt.Sprintf("<%02X> <%s>", codes[i], utf16Hex(text[byte(codes[i])])))
+		}
+		i = j
+	}
+	writeCMapSections(b, "bfchar", singles)
+	writeCMapSections(b, "bfrange", ranges)
+
+	b.WriteString("endcmap\n")
+	b.WriteString("CMapName currentdict /CMap defineresource pop\n")
+	b.WriteString("end\nend\n")
+	return s
+}
+
+// consecutive reports whether a and b are single characters in the Basic
+// Multilingual Plane, with b following a, so that they can be part of the
+// same bfrange entry. A bfrange increments only the last byte of the
+// destination, so the run can't cross a multiple of 256.
+func consecutive(a, b string) bool {
+	ra, rb := []rune(a), []rune(b)
+	return len(ra) == 1 && len(rb) == 1 && rb[0] == ra[0]+1 && rb[0] < 0x10000 && rb[0]&0xff != 0
+}
+
+// writeCMapSections writes CMap entries of the given kind (bfchar or
+// bfrange), in sections of at most 100 entries.
+func writeCMapSections(b *bytes.Buffer, kind string, entries []string) {
+	for len(entries) > 0 {
+		n := len(entries)
+		if n > 100 {
+			n = 100
+		}
+		fmt.Fprintf(b, "%d begin%s\n", n, kind)
+		for _, e := range entries[:n] {
+			b.WriteString(e)
+			b.WriteByte('\n')
+		}
+		fmt.Fprintf(b, "end%s\n", kind)
+		entries = entries[n:]
+	}
+}
+
+// utf16Hex returns s encoded as UTF-16BE, in hexadecimal.
+func utf16Hex(s string) string {
+	var b bytes.Buffer
+	for _, u := range utf16.Encode([]rune(s)) {
+		fmt.Fprintf(&b, "%04X", u)
+	}
+	return b.String()
+}
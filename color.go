@@ -0,0 +1,157 @@
+package pdf
+
+import "fmt"
+
+// A Color is a color value that can be used with SetFillColor and
+// SetStrokeColor.
+type Color interface {
+	// setColor writes the operator to select c as the current fill color,
+	// or as the current stroke color if stroke is true.
+	setColor(p *Page, stroke bool)
+}
+
+// Gray is a DeviceGray color. 0 is black and 1 is white.
+type Gray float64
+
+func (g Gray) setColor(p *Page, stroke bool) {
+	p.contents.num(float64(g))
+	if stroke {
+		p.contents.op("G")
+	} else {
+		p.contents.op("g")
+	}
+}
+
+// RGB is a DeviceRGB color. Each component is in the range from 0 to 1.
+type RGB struct {
+	R, G, B float64
+}
+
+func (c RGB) setColor(p *Page, stroke bool) {
+	p.contents.num(c.R, c.G, c.B)
+	if stroke {
+		p.contents.op("RG")
+	} else {
+		p.contents.op("rg")
+	}
+}
+
+// CMYK is a DeviceCMYK color. Each component is in the range from 0 to 1.
+type CMYK struct {
+	C, M, Y, K float64
+}
+
+func (c CMYK) setColor(p *Page, stroke bool) {
+	p.contents.num(c.C, c.M, c.Y, c.K)
+	if stroke {
+		p.contents.op("K")
+	} else {
+		p.contents.op("k")
+	}
+}
+
+// SetFillColor sets the color to be used by Fill.
+func (p *Page) SetFillColor(c Color) {
+	c.setColor(p, false)
+	p.state.fill = c
+}
+
+// SetStrokeColor sets the color to be used by Stroke.
+func (p *Page) SetStrokeColor(c Color) {
+	c.setColor(p, true)
+	p.state.stroke = c
+}
+
+// A ColorSpace is an ICC-based color space, defined by an embedded ICC
+// profile, or a Separation color space for a single colorant.
+type ColorSpace struct {
+	components int
+	profile    *stream
+
+	// For a Separation color space, separation is the colorant name, and
+	// alternate is the CMYK color used for full tint when the colorant
+	// isn't available.
+	separation string
+	alternate  CMYK
+}
+
+// LoadICCProfile creates a color space from the ICC profile in data.
+// Components is the number of color components in the profile's color space
+// (1 for gray, 3 for RGB or Lab, or 4 for CMYK).
+func (d *Document) LoadICCProfile(data []byte, components int) *ColorSpace {
+	cs := &ColorSpace{
+		components: components,
+		profile:    new(stream),
+	}
+	cs.profile.b.Write(data)
+	cs.profile.extraData = fmt.Sprintf("/N %d", components)
+	switch components {
+	case 1:
+		cs.profile.extraData += " /Alternate /DeviceGray"
+	case 3:
+		cs.profile.extraData += " /Alternate /DeviceRGB"
+	case 4:
+		cs.profile.extraData += " /Alternate /DeviceCMYK"
+	}
+	return cs
+}
+
+// NewSeparation returns a Separation color space for the colorant name,
+// such as a spot color. Its colors have a single component, the tint, from
+// 0 to 1; devices that can't produce the colorant use alternate, scaled by
+// the tint, instead.
+func NewSeparation(name string, alternate CMYK) *ColorSpace {
+	return &ColorSpace{
+		components: 1,
+		separation: name,
+		alternate:  alternate,
+	}
+}
+
+// Registration is the color used for printers' marks that must appear on
+// every separation, such as crop marks and registration targets.
+var Registration = NewSeparation("All", CMYK{1, 1, 1, 1}).Color(1)
+
+func (cs *ColorSpace) writeTo(e *encoder) {
+	if cs.profile == nil {
+		a := cs.alternate
+		fmt.Fprintf(e, "[/Separation %s /DeviceCMYK << /FunctionType 2 /Domain [0 1] /C0 [0 0 0 0] /C1 [%s %s %s %s] /N 1 >>]", formatName(cs.separation), formatNum(a.C), formatNum(a.M), formatNum(a.Y), formatNum(a.K))
+		return
+	}
+	fmt.Fprintf(e, "[/ICCBased %d 0 R]", e.getRef(cs.profile))
+}
+
+// Color returns a color in cs. There should be one component value for each
+// of the color space's components, each in the range from 0 to 1.
+func (cs *ColorSpace) Color(components ...float64) Color {
+	return iccColor{cs, components}
+}
+
+type iccColor struct {
+	space      *ColorSpace
+	components []float64
+}
+
+func (c iccColor) setColor(p *Page, stroke bool) {
+	csID, ok := p.colorSpaces[c.space]
+	if !ok {
+		if p.colorSpaces == nil {
+			p.colorSpaces = make(map[*ColorSpace]int)
+		}
+		csID = len(p.colorSpaces)
+		p.colorSpaces[c.space] = csID
+	}
+
+	p.contents.name(fmt.Sprintf("CS%d", csID))
+	if stroke {
+		p.contents.op("CS")
+	} else {
+		p.contents.op("cs")
+	}
+	p.contents.num(c.components...)
+	if stroke {
+		p.contents.op("SCN")
+	} else {
+		p.contents.op("scn")
+	}
+}
@@ -0,0 +1,118 @@
+package pdf
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// These methods write the parts of content-stream operations, each
+// followed by whitespace, so that the drawing methods don't need to handle
+// formatting and spacing themselves.
+
+// num writes numbers.
+func (s *stream) num(values ...float64) {
+	for _, v := range values {
+		s.b.WriteString(formatNum(v))
+		s.b.WriteByte(' ')
+	}
+}
+
+// name writes a name object, such as /F0.
+func (s *stream) name(n string) {
+	s.b.WriteByte('/')
+	s.b.WriteString(n)
+	s.b.WriteByte(' ')
+}
+
+// str writes a string object.
+func (s *stream) str(text string) {
+	s.b.WriteString(quoteString(text))
+	s.b.WriteByte(' ')
+}
+
+// dict writes a dictionary, with the given entries.
+func (s *stream) dict(entries string) {
+	s.b.WriteString("<< ")
+	s.b.WriteString(entries)
+	s.b.WriteString(" >> ")
+}
+
+// array writes an array of values that have already been formatted, such as
+// the operand of TJ.
+func (s *stream) array(values []string) {
+	fmt.Fprint(&s.b, values)
+	s.b.WriteByte(' ')
+}
+
+// op writes an operator. Operators that finish a painting operation or
+// restore the graphics state are followed by a newline instead of a space.
+func (s *stream) op(op string) {
+	s.b.WriteString(op)
+	switch op {
+	case "S", "f", "f*", "B", "B*", "n", "Q":
+		s.b.WriteByte('\n')
+	default:
+		s.b.WriteByte(' ')
+	}
+}
+
+// formatNum formats v as a PDF number. It never uses exponential notation
+// (which PDF doesn't support), and it rounds to 5 decimal places, which is
+// more precise than any output device needs.
+func formatNum(v float64) string {
+	s := strconv.FormatFloat(v, 'f', 5, 64)
+	s = strings.TrimRight(s, "0")
+	s = strings.TrimSuffix(s, ".")
+	if s == "-0" || s == "" {
+		s = "0"
+	}
+	return s
+}
+
+// formatName formats n as a PDF name object, including the leading slash.
+// Characters that can't appear in a name literally are written as #xx
+// escapes.
+func formatName(n string) string {
+	var b strings.Builder
+	b.WriteByte('/')
+	for i := 0; i < len(n); i++ {
+		c := n[i]
+		if c < '!' || c > '~' || strings.IndexByte("#%()/<>[]{}", c) >= 0 {
+			fmt.Fprintf(&b, "#%02X", c)
+		} else {
+			b.WriteByte(c)
+		}
+	}
+	return b.String()
+}
+
+// textString formats s as a PDF text string: a literal string if it is
+// plain ASCII, or a UTF-16BE hex string with a byte order mark otherwise.
+func textString(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			return "<FEFF" + utf16Hex(s) + ">"
+		}
+	}
+	return quoteString(s)
+}
+
+// pdfDocString formats s as a literal string in PDFDocEncoding. Characters
+// that PDFDocEncoding can't represent are replaced with '_'. (Only the
+// Latin-1 range, which PDFDocEncoding shares with Unicode, is used.) Bytes
+// above 0x7F are written as octal escapes, so the result is plain ASCII.
+func pdfDocString(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r > 0xff || r >= 0x7f && r < 0xa1 || r == 0xad:
+			b.WriteByte('_')
+		case r >= 0x80:
+			fmt.Fprintf(&b, "\\%03o", r)
+		default:
+			b.WriteString(stringEscaper.Replace(string(r)))
+		}
+	}
+	return "(" + b.String() + ")"
+}
@@ -0,0 +1,383 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"sort"
+	"unicode/utf16"
+)
+
+// A FitMode specifies how a Destination fits its page in the window.
+type FitMode int
+
+const (
+	// FitXYZ puts the point (Left, Top) at the upper left corner of the
+	// window, at the magnification Zoom (where 1 is 100%).
+	FitXYZ FitMode = iota
+
+	// Fit fits the whole page in the window.
+	Fit
+
+	// FitH fits the width of the page in the window, with Top at the top
+	// of the window.
+	FitH
+
+	// FitV fits the height of the page in the window, with Left at the
+	// left edge of the window.
+	FitV
+
+	// FitR fits the rectangle Rect in the window.
+	FitR
+)
+
+// A Destination is a view of a page, for links, bookmarks, named
+// destinations, and the document's open action. Each one chooses its own
+// Mode, and which of the other fields are used depends on it. For FitXYZ,
+// FitH, and FitV, a coordinate that is NaN (math.NaN()) leaves that
+// coordinate as it was. A Zoom of 0 (the zero value) keeps the viewer's
+// current magnification, rather than zooming out to nothing; so does a
+// negative or NaN Zoom.
+type Destination struct {
+	Page *Page
+	Mode FitMode
+
+	Left, Top float64
+	Zoom      float64
+	Rect      Rect
+}
+
+// format returns the destination as a PDF array.
+func (d Destination) format(e *encoder) string {
+	p := d.Page
+	top := d.Top
+	rect := d.Rect
+	if p.topDown {
+		top = p.height - top
+		rect.Y0, rect.Y1 = p.height-rect.Y1, p.height-rect.Y0
+	}
+	coord := func(v float64) string {
+		if math.IsNaN(v) {
+			return "null"
+		}
+		return formatNum(v)
+	}
+
+	ref := e.getRef(p)
+	switch d.Mode {
+	case Fit:
+		return fmt.Sprintf("[%d 0 R /Fit]", ref)
+	case FitH:
+		return fmt.Sprintf("[%d 0 R /FitH %s]", ref, coord(top))
+	case FitV:
+		return fmt.Sprintf("[%d 0 R /FitV %s]", ref, coord(d.Left))
+	case FitR:
+		return fmt.Sprintf("[%d 0 R /FitR %s %s %s %s]", ref, formatNum(rect.X0), formatNum(rect.Y0), formatNum(rect.X1), formatNum(rect.Y1))
+	default:
+		zoom := coord(d.Zoom)
+		if !(d.Zoom > 0) {
+			zoom = "null"
+		}
+		return fmt.Sprintf("[%d 0 R /XYZ %s %s %s]", ref, coord(d.Left), coord(top), zoom)
+	}
+}
+
+// A DestsForm specifies where the named destinations added with
+// AddDestination are stored in the document catalog.
+type DestsForm int
+
+const (
+	// DestsNameTree stores destinations in the /Dests entry of the
+	// catalog's /Names dictionary (PDF 1.2 and later). This is the default,
+	// and what current viewers expect.
+	DestsNameTree DestsForm = iota
+
+	// DestsDictionary stores destinations in the catalog's /Dests
+	// dictionary, the PDF 1.1 form. Some older viewers and link-checking
+	// tools only look there.
+	DestsDictionary
+
+	// DestsBoth stores destinations in both places, for the widest
+	// compatibility.
+	DestsBoth
+)
+
+// AddDestination adds a named destination, which shows page p, scrolled so
+// that top is at the top of the window. Named destinations can be the
+// target of links from other documents (for example, with a URL ending in
+// #name).
+func (d *Document) AddDestination(name string, p *Page, top float64) {
+	d.AddNamedDestination(name, Destination{Page: p, Left: math.NaN(), Top: top})
+}
+
+// AddNamedDestination is like AddDestination, but it takes a Destination,
+// so that any fit mode can be used.
+func (d *Document) AddNamedDestination(name string, dest Destination) {
+	if d.dests == nil {
+		d.dests = make(map[string]Destination)
+	}
+	d.dests[name] = dest
+}
+
+// SetOpenAction sets the view that the document opens to.
+func (d *Document) SetOpenAction(dest Destination) {
+	d.openAction = &dest
+}
+
+// A linkAnnot is a link annotation that goes to a destination in the same
+// document.
+type linkAnnot struct {
+	rect Rect
+	dest Destination
+}
+
+func (a *linkAnnot) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Type /Annot /Subtype /Link /Rect %s /Border [0 0 0] /Dest %s >>", a.rect, a.dest.format(e))
+}
+
+// LinkTo makes the rectangle r on the page a link to dest.
+func (p *Page) LinkTo(r Rect, dest Destination) {
+	if p.topDown {
+		r.Y0, r.Y1 = p.height-r.Y1, p.height-r.Y0
+	}
+	p.annots = append(p.annots, &linkAnnot{r, dest})
+}
+
+// A Bookmark is an item in the document outline, which viewers show as a
+// navigation panel.
+type Bookmark struct {
+	title    string
+	dest     Destination
+	children []*Bookmark
+
+	// expanded is set by SetExpanded; if it is nil, the document's
+	// bookmark depth applies.
+	expanded *bool
+
+	// parent is the Bookmark or outline root that contains the bookmark,
+	// and prev and next are its siblings; they are set while the outline
+	// is written.
+	parent     object
+	prev, next *Bookmark
+}
+
+// AddBookmark adds a top-level bookmark to the document outline, which
+// goes to dest.
+func (d *Document) AddBookmark(title string, dest Destination) *Bookmark {
+	b := &Bookmark{title: title, dest: dest}
+	d.outline.children = append(d.outline.children, b)
+	return b
+}
+
+// AddChild adds a bookmark nested under b.
+func (b *Bookmark) AddChild(title string, dest Destination) *Bookmark {
+	child := &Bookmark{title: title, dest: dest}
+	b.children = append(b.children, child)
+	return child
+}
+
+// SetExpanded sets whether b's children are shown when the document is
+// opened, overriding the document's bookmark depth. (A collapsed bookmark
+// hides all of its descendants, whether or not they are expanded.)
+func (b *Bookmark) SetExpanded(expanded bool) {
+	b.expanded = &expanded
+}
+
+// SetBookmarkDepth sets how many levels of the outline are shown when the
+// document is opened: with a depth of 1, only the top-level bookmarks; with
+// 2, their children as well; and so on. If depth is negative, all levels
+// are expanded; this is the default. Bookmark.SetExpanded overrides it for
+// individual bookmarks.
+func (d *Document) SetBookmarkDepth(depth int) {
+	d.outline.depth = depth
+	d.outline.depthSet = depth >= 0
+}
+
+// An outlineRoot is the document's outline dictionary.
+type outlineRoot struct {
+	children []*Bookmark
+
+	// depth is the number of levels set by SetBookmarkDepth, if depthSet
+	// is true.
+	depth    int
+	depthSet bool
+}
+
+func (o *outlineRoot) writeTo(e *encoder) {
+	e.WriteString("<< /Type /Outlines ")
+	writeOutlineChildren(e, o, o.children)
+	fmt.Fprintf(e, "/Count %d >>", o.visibleCount(o.children, 0))
+}
+
+// expanded reports whether b, which is at the given level of the outline
+// (0 for the top level), is expanded.
+func (o *outlineRoot) expanded(b *Bookmark, level int) bool {
+	if b.expanded != nil {
+		return *b.expanded
+	}
+	return !o.depthSet || level+1 < o.depth
+}
+
+// visibleCount returns the number of bookmarks in list, which are at the
+// given level, that are shown when the outline is opened: the bookmarks
+// themselves, and the visible descendants of the expanded ones.
+func (o *outlineRoot) visibleCount(list []*Bookmark, level int) int {
+	n := len(list)
+	for _, b := range list {
+		if o.expanded(b, level) {
+			n += o.visibleCount(b.children, level+1)
+		}
+	}
+	return n
+}
+
+// root returns the outline root that b is in, and b's level in the
+// outline. It works only after b's parent has been set.
+func (b *Bookmark) root() (o *outlineRoot, level int) {
+	for {
+		switch p := b.parent.(type) {
+		case *outlineRoot:
+			return p, level
+		case *Bookmark:
+			b = p
+			level++
+		default:
+			return new(outlineRoot), level
+		}
+	}
+}
+
+func (b *Bookmark) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Title %s /Parent %d 0 R /Dest %s ", textString(b.title), e.getRef(b.parent), b.dest.format(e))
+	if b.prev != nil {
+		fmt.Fprintf(e, "/Prev %d 0 R ", e.getRef(b.prev))
+	}
+	if b.next != nil {
+		fmt.Fprintf(e, "/Next %d 0 R ", e.getRef(b.next))
+	}
+	writeOutlineChildren(e, b, b.children)
+	if len(b.children) > 0 {
+		// The count is negative if b is collapsed; either way, its
+		// magnitude is the number of descendants shown when b is expanded.
+		o, level := b.root()
+		count := o.visibleCount(b.children, level+1)
+		if !o.expanded(b, level) {
+			count = -count
+		}
+		fmt.Fprintf(e, "/Count %d ", count)
+	}
+	e.WriteString(">>")
+}
+
+// writeOutlineChildren writes the entries that link parent to its children,
+// and the children to their siblings.
+func writeOutlineChildren(e *encoder, parent object, children []*Bookmark) {
+	if len(children) == 0 {
+		return
+	}
+	for i, c := range children {
+		c.parent = parent
+		c.prev, c.next = nil, nil
+		if i > 0 {
+			c.prev = children[i-1]
+		}
+		if i < len(children)-1 {
+			c.next = children[i+1]
+		}
+	}
+	fmt.Fprintf(e, "/First %d 0 R /Last %d 0 R ", e.getRef(children[0]), e.getRef(children[len(children)-1]))
+}
+
+// SetDestsForm sets the form in which named destinations are written. The
+// default depends on the declared PDF version (see SetPDFVersion):
+// DestsNameTree for PDF 1.2 and later, and DestsDictionary for earlier
+// versions, which don't have name trees. Use DestsBoth for consumers that
+// only support the PDF 1.1 form in a file that declares a later version.
+// Validate reports a name tree in a file that declares a version earlier
+// than 1.2.
+func (d *Document) SetDestsForm(form DestsForm) {
+	d.destsForm = form
+	d.destsFormSet = true
+}
+
+// destsFormInUse returns the form in which named destinations are written.
+func (d *Document) destsFormInUse() DestsForm {
+	switch {
+	case d.destsFormSet:
+		return d.destsForm
+	case d.versionBefore("1.2"):
+		return DestsDictionary
+	}
+	return DestsNameTree
+}
+
+// writeDests writes the /Dests dictionary for the document's named
+// destinations, if that form is in use.
+func (d *Document) writeDests(e *encoder) {
+	if len(d.dests) == 0 || d.destsFormInUse() == DestsNameTree {
+		return
+	}
+	b := new(bytes.Buffer)
+	b.WriteString("<< ")
+	for _, name := range d.destNames() {
+		fmt.Fprintf(b, "%s %s ", formatName(name), d.dests[name].format(e))
+	}
+	b.WriteString(">>")
+	fmt.Fprintf(e, "/Dests %s ", b)
+}
+
+// destsNameTree returns the name tree for the document's named
+// destinations, or "" if there are none or the name tree form is not in
+// use.
+func (d *Document) destsNameTree(e *encoder) string {
+	if len(d.dests) == 0 || d.destsFormInUse() == DestsDictionary {
+		return ""
+	}
+	return nameTree(d.destNames(), func(name string) string {
+		return d.dests[name].format(e)
+	})
+}
+
+// destNames returns the names of the document's named destinations, in
+// sorted order.
+func (d *Document) destNames() []string {
+	names := make([]string, 0, len(d.dests))
+	for name := range d.dests {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
+// nameTree returns a name tree (with a single leaf node) mapping the keys to
+// the values returned by value. The keys are sorted by the bytes of their
+// encoded strings, since that is the order in which readers search for them.
+func nameTree(keys []string, value func(key string) string) string {
+	keys = append([]string(nil), keys...)
+	sort.Slice(keys, func(i, j int) bool {
+		return nameTreeKey(keys[i]) < nameTreeKey(keys[j])
+	})
+	b := new(bytes.Buffer)
+	b.WriteString("<< /Names [")
+	for _, k := range keys {
+		fmt.Fprintf(b, "%s %s ", textString(k), value(k))
+	}
+	b.WriteString("] >>")
+	return b.String()
+}
+
+// nameTreeKey returns the bytes of the string that textString writes for s:
+// s itself if it is ASCII, and otherwise a byte order mark followed by s in
+// UTF-16BE.
+func nameTreeKey(s string) string {
+	for i := 0; i < len(s); i++ {
+		if s[i] >= 0x80 {
+			b := []byte{0xfe, 0xff}
+			for _, u := range utf16.Encode([]rune(s)) {
+				b = append(b, byte(u>>8), byte(u))
+			}
+			return string(b)
+		}
+	}
+	return s
+}
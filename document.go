@@ -1,39 +1,390 @@
 package pdf
 
-import "fmt"
+import (
+	"bytes"
+	"encoding/base64"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+)
 
 // A Document represents a PDF document.
 type Document struct {
-	pages     pageTree
-	fontCache map[string]*Font
+	pages      pageTree
+	fontCache  map[string]*Font
+	imageCache map[string]*Image
+
+	asciiFilter          ASCIIFilter
+	compressionThreshold int
+	compression          Compression
+	pretty               bool
+	minLineWidth         float64
+	maxImageDPI          float64
+	imageDPI             float64
+	language             string
+	margins              Margins
+	openJavaScript       string
+	fullScreen           bool
+
+	// extGStates holds the graphics state dictionaries used in the
+	// document, indexed by their contents.
+	extGStates map[string]*extGState
+
+	header, footer func(p *Page, pageNum, pageCount int)
+	pageNumbering  func(p *Page, pageNum, pageCount int)
+
+	autoTag    bool
+	structTree structTreeRoot
+
+	hyphenator Hyphenator
+
+	defaultFont *Font
+	defaultSize float64
+
+	dests        map[string]Destination
+	destsForm    DestsForm
+	destsFormSet bool
+
+	openAction *Destination
+	outline    outlineRoot
+
+	attachments map[string]*stream
+
+	layers []*Layer
+
+	rawObjects []*rawObject
+
+	docMDP *sigDict
+
+	renderHook RenderHook
+
+	id [2][]byte
+
+	// permanentID is the first part of the generated file identifier,
+	// fixed when the document is first encoded.
+	permanentID []byte
+
+	// version is the PDF version declared in the file header, or "" for
+	// the default.
+	version string
 }
 
 func (d *Document) NewPage(width, height float64) *Page {
+	return d.InsertPage(len(d.pages.pages), width, height)
+}
+
+// InsertPage adds a new page at index i, moving the page currently at that
+// index (and all those after it) one place later in the document. This
+// makes it possible to add pages, such as a table of contents, before pages
+// that have already been created. It panics if i is not in the range
+// [0, PageCount()].
+func (d *Document) InsertPage(i int, width, height float64) *Page {
+	if i < 0 || i > len(d.pages.pages) {
+		panic(fmt.Sprintf("pdf: InsertPage index %d out of range [0, %d]", i, len(d.pages.pages)))
+	}
 	p := &Page{
-		parent:   &d.pages,
-		width:    width,
-		height:   height,
-		contents: new(stream),
+		doc:    d,
+		parent: &d.pages,
+		width:  width,
+		height: height,
+		state:  initialState,
 	}
-	d.pages.pages = append(d.pages.pages, p)
+	p.NewFragment()
+	d.pages.pages = append(d.pages.pages, nil)
+	copy(d.pages.pages[i+1:], d.pages.pages[i:])
+	d.pages.pages[i] = p
 	return p
 }
 
+// MovePage moves the page at index from to index to, shifting the pages in
+// between to make room. It panics if either index is not in the range
+// [0, PageCount()).
+func (d *Document) MovePage(from, to int) {
+	pages := d.pages.pages
+	if from < 0 || from >= len(pages) || to < 0 || to >= len(pages) {
+		panic(fmt.Sprintf("pdf: MovePage indexes %d and %d out of range [0, %d)", from, to, len(pages)))
+	}
+	p := pages[from]
+	if from < to {
+		copy(pages[from:to], pages[from+1:to+1])
+	} else {
+		copy(pages[to+1:from+1], pages[to:from])
+	}
+	pages[to] = p
+}
+
+// PageCount returns the number of pages in the document.
+func (d *Document) PageCount() int {
+	return len(d.pages.pages)
+}
+
+// Page returns the page at index i (starting from 0). It panics if i is
+// not in the range [0, PageCount()), like indexing a slice.
+func (d *Document) Page(i int) *Page {
+	return d.pages.pages[i]
+}
+
+// defaultVersion is the PDF version that documents declare unless
+// SetPDFVersion is called.
+const defaultVersion = "1.7"
+
+// pdfVersions lists the PDF versions, in order.
+var pdfVersions = []string{"1.0", "1.1", "1.2", "1.3", "1.4", "1.5", "1.6", "1.7", "2.0"}
+
+// SetPDFVersion sets the PDF version declared in the file header, such as
+// "1.4". The default is "1.7". Declaring an earlier version changes the
+// defaults of options that have a form for older readers (see SetDestsForm),
+// but it doesn't stop other features from being used; it is up to the
+// caller to use only what the declared version supports.
+func (d *Document) SetPDFVersion(version string) {
+	d.version = version
+}
+
+// pdfVersion returns the PDF version that the document declares.
+func (d *Document) pdfVersion() string {
+	if d.version == "" {
+		return defaultVersion
+	}
+	return d.version
+}
+
+// versionBefore reports whether the document declares a PDF version earlier
+// than v. An unknown version isn't earlier than anything.
+func (d *Document) versionBefore(v string) bool {
+	declared, target := -1, -1
+	for i, s := range pdfVersions {
+		if s == d.pdfVersion() {
+			declared = i
+		}
+		if s == v {
+			target = i
+		}
+	}
+	return declared >= 0 && declared < target
+}
+
+// SetLanguage sets the natural language of the document's text, as a BCP 47
+// language tag such as "en-US".
+func (d *Document) SetLanguage(lang string) {
+	d.language = lang
+}
+
 func (d *Document) writeTo(e *encoder) {
 	pagesRef := e.getRef(&d.pages)
-	fmt.Fprintf(e, "<< /Type /Catalog /Pages %d 0 R >>", pagesRef)
+	fmt.Fprintf(e, "<< /Type /Catalog /Pages %d 0 R ", pagesRef)
+	if d.language != "" {
+		fmt.Fprintf(e, "/Lang %s ", quoteString(d.language))
+	}
+	if len(d.structTree.elems) > 0 {
+		fmt.Fprintf(e, "/StructTreeRoot %d 0 R /MarkInfo << /Marked true >> ", e.getRef(&d.structTree))
+	}
+	for _, o := range d.rawObjects {
+		e.getRef(o)
+	}
+	if d.fullScreen {
+		e.WriteString("/PageMode /FullScreen ")
+	}
+	switch {
+	case d.openAction != nil && d.openJavaScript != "":
+		fmt.Fprintf(e, "/OpenAction << /S /GoTo /D %s /Next << /S /JavaScript /JS %s >> >> ", d.openAction.format(e), textString(d.openJavaScript))
+	case d.openAction != nil:
+		fmt.Fprintf(e, "/OpenAction %s ", d.openAction.format(e))
+	case d.openJavaScript != "":
+		fmt.Fprintf(e, "/OpenAction << /S /JavaScript /JS %s >> ", textString(d.openJavaScript))
+	}
+	if len(d.outline.children) > 0 {
+		fmt.Fprintf(e, "/Outlines %d 0 R ", e.getRef(&d.outline))
+	}
+	d.writeDests(e)
+	d.writeSignatureEntries(e)
+	d.writeLayers(e)
+
+	dests, files := d.destsNameTree(e), d.embeddedFilesTree(e)
+	if dests != "" || files != "" {
+		e.WriteString("/Names << ")
+		if dests != "" {
+			fmt.Fprintf(e, "/Dests %s ", dests)
+		}
+		if files != "" {
+			fmt.Fprintf(e, "/EmbeddedFiles %s ", files)
+		}
+		e.WriteString(">> ")
+	}
+	fmt.Fprint(e, ">>")
+}
+
+// SetOpenJavaScript sets JavaScript code to be run when the document is
+// opened, with an /OpenAction in the document catalog. Many viewers block
+// or ignore document JavaScript for security reasons, so it should be used
+// only where the viewer is known to support it, as with kiosk or receipt
+// printing setups.
+func (d *Document) SetOpenJavaScript(js string) {
+	d.openJavaScript = js
+}
+
+// SetPrintOnOpen makes the document open the print dialog when it is
+// opened, using SetOpenJavaScript. It has the same limitations.
+func (d *Document) SetPrintOnOpen() {
+	d.SetOpenJavaScript("this.print({bUI: true, bSilent: false, bShrinkToFit: true});")
+}
+
+// SetID sets the file identifier written in the document's trailer. By
+// default, both parts of the identifier are generated from an MD5 hash of
+// the document's contents. The first part is fixed the first time the
+// document is encoded, and the second part is recomputed on each encode, so
+// a document that is changed and encoded again keeps its first part but gets
+// a new second part. Encoding the same document twice produces identical
+// output.
+func (d *Document) SetID(id [2][]byte) {
+	d.id = id
+}
+
+// SetHeader sets a function to draw a running header on each page. It is
+// called for each page when the document is encoded, so the total number of
+// pages is known. Pages are numbered starting with 1.
+func (d *Document) SetHeader(f func(p *Page, pageNum, pageCount int)) {
+	d.header = f
+}
+
+// SetFooter sets a function to draw a running footer on each page. It is
+// called for each page when the document is encoded, so the total number of
+// pages is known. Pages are numbered starting with 1.
+func (d *Document) SetFooter(f func(p *Page, pageNum, pageCount int)) {
+	d.footer = f
+}
+
+// SetPageNumbering adds a page number to each page, drawn with the given
+// font and size at (x, y) with the specified alignment. In format, "{page}"
+// is replaced by the page number, and "{pages}" by the total number of
+// pages; for example, "Page {page} of {pages}". The page numbers are drawn
+// along with the header and footer (see SetHeader).
+func (d *Document) SetPageNumbering(format string, font *Font, size float64, x, y float64, align Alignment) {
+	d.pageNumbering = func(p *Page, pageNum, pageCount int) {
+		s := strings.NewReplacer("{page}", strconv.Itoa(pageNum), "{pages}", strconv.Itoa(pageCount)).Replace(format)
+		p.SetFont(font, size)
+		switch align {
+		case AlignCenter:
+			p.Center(x, y, s)
+		case AlignRight:
+			p.Right(x, y, s)
+		default:
+			p.Left(x, y, s)
+		}
+	}
+}
+
+// drawDecorations calls the header and footer functions for each page,
+// drawing into a separate content stream, which is placed before the page's
+// main contents.
+func (d *Document) drawDecorations() {
+	pageCount := len(d.pages.pages)
+	for i, p := range d.pages.pages {
+		p.drawBackground()
+		p.decorations = nil
+		if d.header == nil && d.footer == nil && d.pageNumbering == nil {
+			continue
+		}
+
+		p.decorations = p.drawFragment(func() {
+			p.beginArtifact()
+			if d.header != nil {
+				d.header(p, i+1, pageCount)
+			}
+			if d.footer != nil {
+				d.footer(p, i+1, pageCount)
+			}
+			if d.pageNumbering != nil {
+				d.pageNumbering(p, i+1, pageCount)
+			}
+			p.endArtifact()
+		})
+	}
 }
 
 func (d *Document) Encode() []byte {
-	return new(encoder).encode(d)
+	_, b := d.encode()
+	return b
+}
+
+// encode encodes the document, and returns the encoder as well as the
+// encoded file.
+func (d *Document) encode() (*encoder, []byte) {
+	d.drawDecorations()
+	d.renderThumbnails()
+	e, b := d.encodeFile()
+	d.permanentID = e.permanentID
+	return e, b
+}
+
+// encodeFile does the work of encode, after the headers, footers, and
+// thumbnails are ready.
+func (d *Document) encodeFile() (*encoder, []byte) {
+	// Clear the signature field from a page that was first when the
+	// document was last encoded.
+	for _, p := range d.pages.pages {
+		p.sigField = nil
+	}
+	if d.docMDP != nil && len(d.pages.pages) > 0 {
+		first := d.pages.pages[0]
+		first.sigField = &sigField{sig: d.docMDP, page: first}
+	}
+	e := &encoder{
+		asciiFilter:          d.asciiFilter,
+		compressionThreshold: d.compressionThreshold,
+		compression:          d.compression,
+		pretty:               d.pretty,
+		id:                   d.id,
+		permanentID:          d.permanentID,
+		version:              d.pdfVersion(),
+	}
+	b := e.encode(d)
+	if d.docMDP != nil && e.sigOffset != 0 {
+		fillByteRange(b, e.sigOffset)
+	}
+	return e, b
+}
+
+// DataURI returns the encoded document as a data URI, suitable for use as
+// the src of an iframe or the href of a link in HTML.
+func (d *Document) DataURI() string {
+	return "data:application/pdf;base64," + base64.StdEncoding.EncodeToString(d.Encode())
+}
+
+// WriteFile encodes the document and writes it to the named file.
+func (d *Document) WriteFile(filename string) error {
+	return ioutil.WriteFile(filename, d.Encode(), 0666)
 }
 
 type pageTree struct {
 	pages []*Page
+
+	// resources and mediaBox are the values that the pages inherit from the
+	// page tree node. They are set when the page tree is written, which is
+	// before the pages are.
+	resources string
+	mediaBox  string
 }
 
 func (p *pageTree) writeTo(e *encoder) {
-	fmt.Fprintf(e, "<< /Type /Pages /Count %d /Kids [", len(p.pages))
+	resources := make([]string, len(p.pages))
+	mediaBoxes := make([]string, len(p.pages))
+	for i, page := range p.pages {
+		resources[i] = page.resources(e)
+		mediaBoxes[i] = page.mediaBox()
+	}
+	p.resources = mostCommon(resources)
+	p.mediaBox = mostCommon(mediaBoxes)
+
+	fmt.Fprintf(e, "<< /Type /Pages /Count %d ", len(p.pages))
+	if p.resources != "" {
+		fmt.Fprintf(e, "/Resources %s ", p.resources)
+	}
+	if p.mediaBox != "" {
+		fmt.Fprintf(e, "/MediaBox %s ", p.mediaBox)
+	}
+	fmt.Fprint(e, "/Kids [")
 	for i, page := range p.pages {
 		pageRef := e.getRef(page)
 		if i > 0 {
@@ -44,29 +395,489 @@ func (p *pageTree) writeTo(e *encoder) {
 	e.WriteString("] >>")
 }
 
+// mostCommon returns the string that occurs most often in list, or "" if
+// none occurs more than once.
+func mostCommon(list []string) string {
+	counts := make(map[string]int)
+	best := ""
+	for _, s := range list {
+		counts[s]++
+		if counts[s] > 1 && counts[s] > counts[best] {
+			best = s
+		}
+	}
+	return best
+}
+
 type Page struct {
+	doc         *Document
 	parent      *pageTree
 	width       float64
 	height      float64
+	userUnit    float64
+	boxes       map[string]Rect
+	fragments   []*stream // content streams, in order; contents is the last
 	contents    *stream
+	decorations *stream
+
+	// background is the content stream for the background image, which
+	// is drawn behind everything else, including the decorations.
+	background        *stream
+	backgroundImage   *Image
+	backgroundScaling ImageScaling
+
 	fonts       map[*Font]int
-	currentFont *Font
-	currentSize float64
+	images      map[*Image]int
+	colorSpaces map[*ColorSpace]int
+	extGStates  map[*extGState]bool
+	patterns    map[*Pattern]int
+	shadings    map[*Gradient]int
+	templates   map[*Template]int
+	layers      map[*Layer]int
+
+	// rawResources holds the objects added with RawResource, by category.
+	rawResources map[string][]Ref
+
+	structElems  []*structElem // indexed by MCID
+	readingOrder int
+	artifact     bool // drawing a header or footer
+	currentFont  *Font
+	currentSize  float64
+	leading      float64
+	charSpacing  float64
+
+	// textColor is the color set by SetTextColor; textSaved is set while a
+	// text object is drawn in it, inside a q/Q pair.
+	textColor Color
+	textSaved bool
+
+	// textOverprint is set if text is drawn with fill overprinting on,
+	// because of SetTextBlack.
+	textOverprint bool
+
+	// tm and tlm track the text matrix and the text line matrix, for
+	// drawing text as outlines (see Font.SetOutlines). The outlines are
+	// collected in outlineText until the end of the text object; clipText
+	// is set while ClipText is drawing.
+	tm, tlm     [6]float64
+	outlineText *stream
+	clipText    bool
+
+	// pathStream and pathStart are where the current path began: the
+	// content stream, and the offset in it (see markPathStart).
+	pathStream *stream
+	pathStart  int
+
+	// thumb is the thumbnail set with SetThumbnail. If there isn't one,
+	// hookThumb is the thumbnail made by the document's RenderHook, or
+	// hookErr is the error it returned.
+	thumb     *Image
+	hookThumb *Image
+	hookErr   error
+
+	// topDown is set by SetTopDown.
+	topDown bool
+
+	// redactions are the areas added with Redact, in default user space.
+	redactions []Rect
+
+	// pixelSnap is the resolution set by SetPixelSnap.
+	pixelSnap float64
+
+	// transition is the /Trans dictionary set by SetTransition, and
+	// displayDuration is the /Dur value set by SetDisplayDuration.
+	transition      string
+	displayDuration float64
+
+	// sigField is the DocMDP signature field, on the first page of a
+	// document that will be certified.
+	sigField *sigField
+
+	// annots holds the page's link annotations.
+	annots []*linkAnnot
+
+	// stamps holds the stamp annotations added with PlaceStamp.
+	stamps []*stampAnnot
+
+	// saveDepth is the number of Save calls not yet matched by Restore, and
+	// markDepth is the same for marked-content sequences. If either
+	// goes negative, unbalanced is set.
+	saveDepth  int
+	markDepth  int
+	unbalanced bool
+
+	// state is the current graphics state, as far as it is tracked, and
+	// stateStack holds the states saved by Save.
+	state      drawState
+	stateStack []savedState
+
+	// problems lists errors in the arguments to drawing methods, to be
+	// reported by Validate.
+	problems []string
+
+	// currentX and currentY are the current point of the path being built;
+	// startX and startY are the starting point of the current subpath.
+	currentX, currentY float64
+	startX, startY     float64
+}
+
+// Width returns the width of the page.
+func (p *Page) Width() float64 {
+	return p.width
+}
+
+// Height returns the height of the page.
+func (p *Page) Height() float64 {
+	return p.height
+}
+
+// SetUserUnit sets the size of the unit used for all the coordinates on the
+// page (including its width and height) to u/72 inch, instead of the default
+// 1/72 inch. Since page dimensions are limited to 14,400 units, this is
+// needed for pages larger than 200 inches; for example, with a unit of 10, a
+// page can be up to 2,000 inches on a side.
+func (p *Page) SetUserUnit(u float64) {
+	p.userUnit = u
+}
+
+// Margins specifies the page margins for a document. For two-sided printing,
+// Inner is the margin on the side of the page toward the binding, and Outer
+// is the margin on the opposite side.
+type Margins struct {
+	Top, Bottom, Inner, Outer float64
+}
+
+// SetMargins sets the margins used by Page.ContentBox.
+func (d *Document) SetMargins(m Margins) {
+	d.margins = m
+}
+
+// Index returns the position of p in its document, starting from 0, or -1 if
+// p is not one of the document's pages (for example, if it is the cell of a
+// Pattern).
+func (p *Page) Index() int {
+	if p.parent == nil {
+		return -1
+	}
+	for i, page := range p.parent.pages {
+		if page == p {
+			return i
+		}
+	}
+	return -1
+}
+
+// IsRecto reports whether p is a right-hand page (page 1, 3, 5, ...) when the
+// document is printed two-sided.
+func (p *Page) IsRecto() bool {
+	i := p.Index()
+	return i == -1 || i%2 == 0
+}
+
+// ContentBox returns the part of the page that is inside the margins set with
+// Document.SetMargins. On right-hand pages, the inner margin is on the left;
+// on left-hand pages, it is on the right.
+func (p *Page) ContentBox() (x, y, width, height float64) {
+	m := p.doc.margins
+	left, right := m.Inner, m.Outer
+	if !p.IsRecto() {
+		left, right = right, left
+	}
+	return left, m.Bottom, p.width - left - right, p.height - m.Top - m.Bottom
 }
 
 func (p *Page) writeTo(e *encoder) {
 	fmt.Fprint(e, "<< /Type /Page ")
 	fmt.Fprintf(e, "/Parent %d 0 R ", e.getRef(p.parent))
-	fmt.Fprintf(e, "/Contents %d 0 R ", e.getRef(p.contents))
-	fmt.Fprint(e, "/Resources << ")
-	if len(p.fonts) > 0 {
-		fmt.Fprint(e, "/Font << ")
-		for f, i := range p.fonts {
-			fmt.Fprintf(e, "/F%d %d 0 R ", i, e.getRef(f))
+	contents := p.fragments
+	if p.decorations != nil {
+		contents = append([]*stream{p.decorations}, contents...)
+	}
+	if p.background != nil {
+		contents = append([]*stream{p.background}, contents...)
+	}
+	if len(p.redactions) > 0 {
+		// The redacted content is rewritten as a single stream.
+		s := &stream{content: true}
+		s.b.Write(p.pageContent())
+		contents = []*stream{s}
+	}
+	if len(contents) == 1 {
+		fmt.Fprintf(e, "/Contents %d 0 R ", e.getRef(contents[0]))
+	} else {
+		fmt.Fprint(e, "/Contents [")
+		for i, s := range contents {
+			if i > 0 {
+				e.WriteByte(' ')
+			}
+			fmt.Fprintf(e, "%d 0 R", e.getRef(s))
+		}
+		fmt.Fprint(e, "] ")
+	}
+	if resources := p.resources(e); resources != p.parent.resources {
+		fmt.Fprintf(e, "/Resources %s ", resources)
+	}
+	if mediaBox := p.mediaBox(); mediaBox != p.parent.mediaBox {
+		fmt.Fprintf(e, "/MediaBox %s ", mediaBox)
+	}
+	if len(p.structElems) > 0 {
+		fmt.Fprintf(e, "/StructParents %d ", p.Index())
+	}
+	for _, name := range []string{"CropBox", "BleedBox", "TrimBox", "ArtBox"} {
+		if r, ok := p.boxes[name]; ok {
+			fmt.Fprintf(e, "/%s %s ", name, r)
+		}
+	}
+	if thumb := p.thumbnail(); thumb != nil {
+		fmt.Fprintf(e, "/Thumb %d 0 R ", e.getRef(thumb))
+	}
+	if p.userUnit != 0 && p.userUnit != 1 {
+		fmt.Fprintf(e, "/UserUnit %g ", p.userUnit)
+	}
+	if p.transition != "" {
+		fmt.Fprintf(e, "/Trans %s ", p.transition)
+	}
+	if p.displayDuration > 0 {
+		fmt.Fprintf(e, "/Dur %s ", formatNum(p.displayDuration))
+	}
+	var annots []string
+	if p.sigField != nil {
+		annots = append(annots, fmt.Sprintf("%d 0 R", e.getRef(p.sigField)))
+	}
+	for _, a := range p.annots {
+		if !p.redacted(a.rect) {
+			annots = append(annots, fmt.Sprintf("%d 0 R", e.getRef(a)))
+		}
+	}
+	for _, a := range p.stamps {
+		if !p.redacted(a.rect) {
+			annots = append(annots, fmt.Sprintf("%d 0 R", e.getRef(a)))
 		}
-		fmt.Fprint(e, ">> ")
 	}
-	fmt.Fprint(e, ">> ")
-	fmt.Fprintf(e, "/MediaBox [0 0 %g %g] ", p.width, p.height)
+	if len(annots) > 0 {
+		fmt.Fprintf(e, "/Annots [%s] ", strings.Join(annots, " "))
+	}
 	fmt.Fprint(e, ">>")
 }
+
+// NewFragment starts a new content stream for the page. Everything drawn
+// after it is called goes into the new stream, which is compressed
+// separately from the page's earlier content streams. Splitting the contents
+// of a very large page into fragments keeps each stream to a manageable size.
+func (p *Page) NewFragment() {
+	if p.contents != nil {
+		endFragment(p.contents)
+	}
+	p.contents = &stream{content: true}
+	p.fragments = append(p.fragments, p.contents)
+}
+
+// Prepend calls draw to draw content that is placed in a separate content
+// stream before all of the page's other content, so that it appears behind
+// it. The graphics state and current font are saved before draw is called
+// and restored afterward.
+func (p *Page) Prepend(draw func()) {
+	p.fragments = append([]*stream{p.drawFragment(draw)}, p.fragments...)
+}
+
+// drawFragment calls draw with the page's output redirected to a new
+// content stream, which it returns. The graphics state and text settings are
+// restored afterward, so that drawing can continue where it left off. The
+// fragment starts with no current font and no leading, since settings made in
+// another content stream wouldn't be in effect in it.
+func (p *Page) drawFragment(draw func()) *stream {
+	contents, font, size, leading, charSpacing, textColor, textOverprint, state := p.contents, p.currentFont, p.currentSize, p.leading, p.charSpacing, p.textColor, p.textOverprint, p.state
+	p.contents = &stream{content: true}
+	p.currentFont, p.currentSize = nil, 0
+	p.leading = 0
+	p.charSpacing = 0
+	p.textColor = nil
+	p.textOverprint = false
+	p.state = initialState
+	p.Save()
+	if p.topDown {
+		p.flipY()
+	}
+	draw()
+	p.Restore()
+	fragment := p.contents
+	endFragment(fragment)
+	p.contents, p.currentFont, p.currentSize, p.leading, p.charSpacing, p.textColor, p.textOverprint, p.state = contents, font, size, leading, charSpacing, textColor, textOverprint, state
+	return fragment
+}
+
+// endFragment makes sure that s ends with whitespace, so that the last token
+// in s will not run into the first token of the next content stream when
+// they are concatenated.
+func endFragment(s *stream) {
+	b := s.b.Bytes()
+	if len(b) == 0 {
+		return
+	}
+	switch b[len(b)-1] {
+	case ' ', '\n', '\r', '\t', '\f', 0:
+	default:
+		s.b.WriteByte('\n')
+	}
+}
+
+func (p *Page) mediaBox() string {
+	if r, ok := p.boxes["MediaBox"]; ok {
+		return r.String()
+	}
+	return fmt.Sprintf("[0 0 %g %g]", p.width, p.height)
+}
+
+// MediaBox returns the page's media box: the boundaries of the physical
+// medium it is to be printed on.
+func (p *Page) MediaBox() Rect {
+	if r, ok := p.boxes["MediaBox"]; ok {
+		return r
+	}
+	return Rect{0, 0, p.width, p.height}
+}
+
+// SetMediaBox sets the page's media box, which is normally the rectangle
+// from the origin to the page's width and height. The page's width and
+// height are changed to match r. Note that the methods that work relative
+// to the page's size, such as ContentBox, assume that the origin is at the
+// lower left corner of the media box.
+func (p *Page) SetMediaBox(r Rect) {
+	p.setBox("MediaBox", r)
+	p.width, p.height = r.Width(), r.Height()
+}
+
+// SetCropBox sets the region that the page is cropped to when it is
+// displayed or printed. By default, it is the same as the media box.
+func (p *Page) SetCropBox(r Rect) {
+	p.setBox("CropBox", r)
+}
+
+// SetBleedBox sets the region that the page's contents should be clipped
+// to in a production environment, including any bleed area.
+func (p *Page) SetBleedBox(r Rect) {
+	p.setBox("BleedBox", r)
+}
+
+// SetTrimBox sets the intended dimensions of the finished page after
+// trimming.
+func (p *Page) SetTrimBox(r Rect) {
+	p.setBox("TrimBox", r)
+}
+
+// SetArtBox sets the extent of the page's meaningful content.
+func (p *Page) SetArtBox(r Rect) {
+	p.setBox("ArtBox", r)
+}
+
+func (p *Page) setBox(name string, r Rect) {
+	if p.boxes == nil {
+		p.boxes = make(map[string]Rect)
+	}
+	p.boxes[name] = r
+}
+
+// resources returns the page's resource dictionary.
+func (p *Page) resources(e *encoder) string {
+	b := new(bytes.Buffer)
+	b.WriteString("<< ")
+
+	if len(p.fonts) > 0 || len(p.rawResources["/Font"]) > 0 {
+		fonts := make([]object, len(p.fonts))
+		for f, i := range p.fonts {
+			fonts[i] = f
+		}
+		p.writeResourceDict(b, e, "/Font", "F", fonts)
+	}
+	if len(p.extGStates) > 0 || len(p.rawResources["/ExtGState"]) > 0 {
+		states := make([]*extGState, 0, len(p.extGStates))
+		for gs := range p.extGStates {
+			states = append(states, gs)
+		}
+		sort.Slice(states, func(i, j int) bool { return states[i].id < states[j].id })
+		b.WriteString("/ExtGState << ")
+		for _, gs := range states {
+			fmt.Fprintf(b, "/GS%d %d 0 R ", gs.id, e.getRef(gs))
+		}
+		p.writeRawResources(b, e, "/ExtGState")
+		b.WriteString(">> ")
+	}
+	if len(p.colorSpaces) > 0 || len(p.rawResources["/ColorSpace"]) > 0 {
+		spaces := make([]object, len(p.colorSpaces))
+		for cs, i := range p.colorSpaces {
+			spaces[i] = cs
+		}
+		p.writeResourceDict(b, e, "/ColorSpace", "CS", spaces)
+	}
+	if len(p.patterns) > 0 || len(p.rawResources["/Pattern"]) > 0 {
+		patterns := make([]object, len(p.patterns))
+		for pat, i := range p.patterns {
+			patterns[i] = pat
+		}
+		p.writeResourceDict(b, e, "/Pattern", "P", patterns)
+	}
+	if len(p.shadings) > 0 || len(p.rawResources["/Shading"]) > 0 {
+		shadings := make([]object, len(p.shadings))
+		for g, i := range p.shadings {
+			shadings[i] = g
+		}
+		p.writeResourceDict(b, e, "/Shading", "Sh", shadings)
+	}
+	if len(p.images) > 0 || len(p.templates) > 0 || len(p.rawResources["/XObject"]) > 0 {
+		images := make([]object, len(p.images))
+		for img, i := range p.images {
+			images[i] = img
+		}
+		templates := make([]object, len(p.templates))
+		for t, i := range p.templates {
+			templates[i] = t
+		}
+		b.WriteString("/XObject << ")
+		writeResourceEntries(b, e, "Im", images)
+		writeResourceEntries(b, e, "Tp", templates)
+		p.writeRawResources(b, e, "/XObject")
+		b.WriteString(">> ")
+	}
+	if len(p.layers) > 0 || len(p.rawResources["/Properties"]) > 0 {
+		layers := make([]object, len(p.layers))
+		for l, i := range p.layers {
+			layers[i] = l
+		}
+		p.writeResourceDict(b, e, "/Properties", "OC", layers)
+	}
+
+	// Raw resources in other categories are in dictionaries of their own.
+	var others []string
+	for category := range p.rawResources {
+		switch category {
+		case "/Font", "/ExtGState", "/ColorSpace", "/Pattern", "/Shading", "/XObject", "/Properties":
+		default:
+			others = append(others, category)
+		}
+	}
+	sort.Strings(others)
+	for _, category := range others {
+		p.writeResourceDict(b, e, category, "", nil)
+	}
+
+	b.WriteString(">>")
+	return b.String()
+}
+
+// writeResourceDict writes an entry in a resource dictionary. The resource
+// names are formed from prefix and the objects' indexes in the list. Any
+// raw objects added to the category with RawResource are included too.
+func (p *Page) writeResourceDict(b *bytes.Buffer, e *encoder, category, prefix string, objects []object) {
+	fmt.Fprintf(b, "%s << ", category)
+	writeResourceEntries(b, e, prefix, objects)
+	p.writeRawResources(b, e, category)
+	b.WriteString(">> ")
+}
+
+// writeResourceEntries writes the entries of a resource dictionary for
+// objects, without the surrounding brackets.
+func writeResourceEntries(b *bytes.Buffer, e *encoder, prefix string, objects []object) {
+	for i, o := range objects {
+		fmt.Fprintf(b, "/%s%d %d 0 R ", prefix, i, e.getRef(o))
+	}
+}
@@ -0,0 +1,69 @@
+package pdf
+
+import "testing"
+
+// pageWidths returns the widths of d's pages, which the tests use to tell
+// them apart.
+func pageWidths(d *Document) []float64 {
+	var widths []float64
+	for i := 0; i < d.PageCount(); i++ {
+		widths = append(widths, d.Page(i).width)
+	}
+	return widths
+}
+
+func TestInsertAndMovePage(t *testing.T) {
+	d := new(Document)
+	d.NewPage(1, 100)
+	d.NewPage(2, 100)
+	d.InsertPage(0, 0, 100)
+	d.InsertPage(3, 3, 100)
+	d.MovePage(0, 3)
+	d.MovePage(3, 1)
+
+	want := []float64{1, 0, 2, 3}
+	got := pageWidths(d)
+	for i := range want {
+		if len(got) != len(want) || got[i] != want[i] {
+			t.Fatalf("pages in order %v, want %v", got, want)
+		}
+		if d.Page(i).Index() != i {
+			t.Errorf("page %d has index %d", i, d.Page(i).Index())
+		}
+	}
+}
+
+func TestInsertPageOutOfRange(t *testing.T) {
+	d := new(Document)
+	d.NewPage(612, 792)
+	for _, i := range []int{-1, 2} {
+		func() {
+			defer func() {
+				if recover() == nil {
+					t.Errorf("InsertPage(%d) didn't panic", i)
+				}
+			}()
+			d.InsertPage(i, 612, 792)
+		}()
+		if d.PageCount() != 1 {
+			t.Fatalf("InsertPage(%d) changed the page count to %d", i, d.PageCount())
+		}
+	}
+}
+
+func TestFooterStartsWithNoLeading(t *testing.T) {
+	d := new(Document)
+	footerLeading := -1.0
+	d.SetFooter(func(p *Page, pageNum, pageCount int) {
+		footerLeading = p.leading
+	})
+	p := d.NewPage(612, 792)
+	p.SetLeading(20)
+	d.Encode()
+	if footerLeading != 0 {
+		t.Errorf("footer started with leading %g; the footer's content stream comes first, where it is 0", footerLeading)
+	}
+	if p.leading != 20 {
+		t.Errorf("page leading is %g after drawing the footer, want 20", p.leading)
+	}
+}
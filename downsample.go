@@ -0,0 +1,174 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/hex"
+	"image"
+	"image/color"
+	"image/jpeg"
+	"math"
+	"strings"
+
+	"golang.org/x/image/ccitt"
+	xdraw "golang.org/x/image/draw"
+)
+
+// SetMaxImageDPI sets a maximum resolution for images. When an image is
+// drawn at a size that would give it a higher resolution than dpi pixels
+// per inch (in the page's default coordinates), a downsampled copy is
+// embedded instead. The copies are cached, so drawing the same image at the
+// same size more than once embeds it only once. JPEG images are
+// recompressed as JPEG; others are compressed losslessly as usual. The
+// default, 0, leaves images at their full resolution.
+func (d *Document) SetMaxImageDPI(dpi float64) {
+	d.maxImageDPI = dpi
+}
+
+// limitResolution returns img, or a downsampled copy if drawing it at the
+// given size would exceed the document's maximum image resolution.
+func (p *Page) limitResolution(img *Image, width, height float64) *Image {
+	dpi := p.doc.maxImageDPI
+	if dpi <= 0 {
+		return img
+	}
+	w := int(math.Ceil(math.Abs(width) / 72 * dpi))
+	h := int(math.Ceil(math.Abs(height) / 72 * dpi))
+	if w < 1 {
+		w = 1
+	}
+	if h < 1 {
+		h = 1
+	}
+	if w >= img.width && h >= img.height {
+		return img
+	}
+	if w > img.width {
+		w = img.width
+	}
+	if h > img.height {
+		h = img.height
+	}
+
+	size := image.Pt(w, h)
+	if scaled, ok := img.downsampled[size]; ok {
+		return scaled
+	}
+	scaled := img.downsample(size)
+	if scaled == nil {
+		return img
+	}
+	if img.downsampled == nil {
+		img.downsampled = make(map[image.Point]*Image)
+	}
+	img.downsampled[size] = scaled
+	return scaled
+}
+
+// downsample returns a copy of img scaled to size, or nil if its pixels
+// can't be decoded.
+func (img *Image) downsample(size image.Point) *Image {
+	src := img.pixels()
+	if src == nil {
+		return nil
+	}
+	isJPEG := img.data.filter == "/DCTDecode"
+
+	var dst xdraw.Image
+	switch src.(type) {
+	case *image.Gray:
+		dst = image.NewGray(image.Rectangle{Max: size})
+	case *image.CMYK:
+		dst = image.NewCMYK(image.Rectangle{Max: size})
+	default:
+		dst = image.NewRGBA(image.Rectangle{Max: size})
+	}
+	xdraw.CatmullRom.Scale(dst, dst.Bounds(), src, src.Bounds(), xdraw.Src, nil)
+
+	if !isJPEG {
+		result := NewImage(dst)
+		result.mask = img.mask
+		return result
+	}
+	b := new(bytes.Buffer)
+	if err := jpeg.Encode(b, dst, &jpeg.Options{Quality: 90}); err != nil {
+		return nil
+	}
+	result := &Image{
+		width:            size.X,
+		height:           size.Y,
+		colorSpace:       img.colorSpace,
+		bitsPerComponent: 8,
+		data:             &stream{filter: "/DCTDecode"},
+		mask:             img.mask,
+	}
+	result.data.b.Write(b.Bytes())
+	return result
+}
+
+// pixels decodes the image data of img, including its alpha channel. The
+// original image isn't kept after NewImage converts it, since it would use
+// a lot of memory for something that is only needed with SetMaxImageDPI.
+// It returns nil for a stencil mask, or if the data can't be decoded.
+func (img *Image) pixels() image.Image {
+	if img.imageMask {
+		return nil
+	}
+	r := image.Rect(0, 0, img.width, img.height)
+	data := img.data.b.Bytes()
+	switch {
+	case img.data.filter == "/DCTDecode":
+		decoded, err := jpeg.Decode(bytes.NewReader(data))
+		if err != nil {
+			return nil
+		}
+		return decoded
+
+	case img.data.filter == "/CCITTFaxDecode":
+		dst := image.NewGray(r)
+		if err := ccitt.DecodeIntoGray(dst, bytes.NewReader(data), ccitt.MSB, ccitt.Group4, nil); err != nil {
+			return nil
+		}
+		return dst
+
+	case img.data.filter != "" || img.bitsPerComponent != 8:
+		return nil
+
+	case img.colorSpace == "/DeviceGray" && len(data) == img.width*img.height:
+		return &image.Gray{Pix: data, Stride: img.width, Rect: r}
+
+	case img.colorSpace == "/DeviceCMYK" && len(data) == 4*img.width*img.height:
+		return &image.CMYK{Pix: data, Stride: 4 * img.width, Rect: r}
+
+	case img.colorSpace == "/DeviceRGB" && len(data) == 3*img.width*img.height:
+		dst := image.NewNRGBA(r)
+		var alpha []byte
+		if img.sMask != nil {
+			alpha = img.sMask.b.Bytes()
+		}
+		for i := 0; i < img.width*img.height; i++ {
+			copy(dst.Pix[4*i:], data[3*i:3*i+3])
+			dst.Pix[4*i+3] = 0xff
+			if i < len(alpha) {
+				dst.Pix[4*i+3] = alpha[i]
+			}
+		}
+		return dst
+
+	case strings.HasPrefix(img.colorSpace, "[/Indexed /DeviceRGB ") && len(data) == img.width*img.height:
+		start := strings.IndexByte(img.colorSpace, '<')
+		end := strings.IndexByte(img.colorSpace, '>')
+		if start == -1 || end < start {
+			return nil
+		}
+		rgb, err := hex.DecodeString(img.colorSpace[start+1 : end])
+		if err != nil {
+			return nil
+		}
+		var palette color.Palette
+		for i := 0; i+2 < len(rgb); i += 3 {
+			palette = append(palette, color.RGBA{rgb[i], rgb[i+1], rgb[i+2], 0xff})
+		}
+		return &image.Paletted{Pix: data, Stride: img.width, Rect: r, Palette: palette}
+	}
+	return nil
+}
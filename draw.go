@@ -1,85 +1,416 @@
 package pdf
 
-import "fmt"
+import "math"
 
 // MoveTo starts a new path or subpath at x, y.
 func (p *Page) MoveTo(x, y float64) {
-	fmt.Fprint(p.contents, x, y, " m ")
+	p.markPathStart()
+	p.contents.num(x, y)
+	p.contents.op("m")
+	p.currentX, p.currentY = x, y
+	p.startX, p.startY = x, y
 }
 
 // LineTo adds a straight line to the current path.
 func (p *Page) LineTo(x, y float64) {
-	fmt.Fprint(p.contents, x, y, " l ")
+	p.contents.num(x, y)
+	p.contents.op("l")
+	p.currentX, p.currentY = x, y
 }
 
 // CurveTo appends a cubic Bézier curve to the current path.
 func (p *Page) CurveTo(x1, y1, x2, y2, x3, y3 float64) {
-	fmt.Fprint(p.contents, x1, y1, x2, y2, x3, y3, " c ")
+	p.contents.num(x1, y1, x2, y2, x3, y3)
+	p.contents.op("c")
+	p.currentX, p.currentY = x3, y3
 }
 
 // ClosePath closes the current subpath with a straight line to its starting
 // point.
 func (p *Page) ClosePath() {
-	fmt.Fprint(p.contents, "h ")
+	p.contents.op("h")
+	p.currentX, p.currentY = p.startX, p.startY
+}
+
+// CurrentPoint returns the current point of the path being built: the end
+// point of the last segment added with MoveTo, LineTo, CurveTo, or ClosePath.
+func (p *Page) CurrentPoint() (x, y float64) {
+	return p.currentX, p.currentY
 }
 
 // Stroke strokes the current path.
 func (p *Page) Stroke() {
-	fmt.Fprint(p.contents, "S\n")
+	p.snapPath()
+	p.contents.op("S")
 }
 
 // Fill fills the current path.
 func (p *Page) Fill() {
-	fmt.Fprint(p.contents, "f\n")
+	p.contents.op("f")
 }
 
 // FillAndStroke fills and strokes the current path.
 func (p *Page) FillAndStroke() {
-	fmt.Fprint(p.contents, "B\n")
+	p.snapPath()
+	p.contents.op("B")
 }
 
-// SetLineWidth sets the width of the line to be drawn by Stroke.
+// SetLineWidth sets the width of the line to be drawn by Stroke. A width of
+// 0 requests a hairline: the thinnest line the output device can draw. Since
+// that can be nearly invisible on a high-resolution printer, a minimum line
+// width can be set with Document.SetMinLineWidth.
 func (p *Page) SetLineWidth(w float64) {
-	fmt.Fprint(p.contents, w, " w ")
+	if w < p.doc.minLineWidth {
+		w = p.doc.minLineWidth
+	}
+	p.contents.num(w)
+	p.contents.op("w")
+	p.state.lineWidth = w
+}
+
+// SetDash sets the dash pattern used by Stroke. The values in pattern
+// alternate between the lengths of dashes and of the gaps between them;
+// phase is the distance into the pattern at which to start. An empty pattern
+// gives a solid line.
+func (p *Page) SetDash(pattern []float64, phase float64) {
+	dashes := make([]string, len(pattern))
+	for i, v := range pattern {
+		dashes[i] = formatNum(v)
+	}
+	p.contents.array(dashes)
+	p.contents.num(phase)
+	p.contents.op("d")
+	p.state.dash = append([]float64(nil), pattern...)
+	p.state.dashPhase = phase
 }
 
 // FillGray sets a grayscale value to be used by Fill.
 // 0 is black and 1 is white.
 func (p *Page) FillGray(g float64) {
-	fmt.Fprint(p.contents, g, " g ")
+	p.SetFillColor(Gray(g))
 }
 
 // StrokeGray sets a grayscale value to be used by Stroke.
 // 0 is black and 1 is white.
 func (p *Page) StrokeGray(g float64) {
-	fmt.Fprint(p.contents, g, " G ")
+	p.SetStrokeColor(Gray(g))
 }
 
 // FillRGB sets an RGB color to be used by Fill.
 // Each component is in the range from 0 to 1.
 func (p *Page) FillRGB(r, g, b float64) {
-	fmt.Fprint(p.contents, r, g, b, " rg ")
+	p.SetFillColor(RGB{r, g, b})
 }
 
 // StrokeRGB sets an RGB color to be used by Stroke.
 // Each component is in the range from 0 to 1.
 func (p *Page) StrokeRGB(r, g, b float64) {
-	fmt.Fprint(p.contents, r, g, b, " RG ")
+	p.SetStrokeColor(RGB{r, g, b})
 }
 
 // FillCMYK sets an CMYK color to be used by Fill.
 // Each component is in the range from 0 to 1.
 func (p *Page) FillCMYK(c, m, y, k float64) {
-	fmt.Fprint(p.contents, c, m, y, k, " k ")
+	p.SetFillColor(CMYK{c, m, y, k})
 }
 
 // StrokeCMYK sets an CMYK color to be used by Stroke.
 // Each component is in the range from 0 to 1.
 func (p *Page) StrokeCMYK(c, m, y, k float64) {
-	fmt.Fprint(p.contents, c, m, y, k, " K ")
+	p.SetStrokeColor(CMYK{c, m, y, k})
 }
 
 // Translate offsets the page's coordinate system by x and y.
 func (p *Page) Translate(x, y float64) {
-	fmt.Fprintf(p.contents, "1 0 0 1 %g %g cm ", x, y)
+	p.contents.num(1, 0, 0, 1, x, y)
+	p.contents.op("cm")
+}
+
+// Transform modifies the page's coordinate system by concatenating the
+// matrix [a b c d e f] with the current transformation matrix.
+func (p *Page) Transform(a, b, c, d, e, f float64) {
+	p.contents.num(a, b, c, d, e, f)
+	p.contents.op("cm")
+}
+
+// Skew skews the page's coordinate system: the x axis is rotated
+// counterclockwise by alphaDeg degrees, and the y axis clockwise by betaDeg
+// degrees. Like the other transformations, it is usually done between Save
+// and Restore.
+//
+// Skewing the y axis synthesizes an oblique style from an upright font; a
+// slant of about 12 degrees looks like a typical italic:
+//
+//	p.Save()
+//	p.Translate(x, y)
+//	p.Skew(0, 12)
+//	p.BeginText()
+//	p.SetFont(regular, 12)
+//	p.ShowText("Oblique text")
+//	p.EndText()
+//	p.Restore()
+func (p *Page) Skew(alphaDeg, betaDeg float64) {
+	p.contents.num(1, math.Tan(alphaDeg*math.Pi/180), math.Tan(betaDeg*math.Pi/180), 1, 0, 0)
+	p.contents.op("cm")
+}
+
+// SetTopDown switches the page to top-down coordinates, with the origin at
+// the top left corner of the page and y increasing downward, as in most
+// screen and image coordinate systems. It must be called before anything
+// is drawn on the page.
+//
+// The coordinate system is flipped with a transformation at the beginning
+// of the page's content (and of each content stream added with Prepend,
+// and the headers and footers). So all of the path construction methods
+// take top-down coordinates, and the rectangle methods take the top left
+// corner instead of the bottom left one. Text and images are flipped back
+// so that they are right side up: text methods position the baseline at
+// the y coordinate given, and images and templates are placed by their
+// top left corners. TextMove and TextMatrix also use the flipped
+// direction for y.
+//
+// The layout methods that work downward from a starting point by
+// decreasing y (such as Multiline's return value, paragraphs, flows,
+// tables, charts, and Render) are written for the default coordinates,
+// and don't give the expected results in top-down mode.
+func (p *Page) SetTopDown() {
+	if p.topDown {
+		return
+	}
+	p.topDown = true
+	p.flipY()
+}
+
+// flipY flips the coordinate system for top-down mode.
+func (p *Page) flipY() {
+	p.Transform(1, 0, 0, -1, 0, p.height)
+}
+
+// Save pushes a copy of the current graphics state onto the graphics state
+// stack. Each call to Save must be matched by a call to Restore.
+func (p *Page) Save() {
+	p.contents.op("q")
+	p.saveDepth++
+	p.stateStack = append(p.stateStack, savedState{p.state, p.currentFont, p.currentSize, p.leading, p.charSpacing})
+}
+
+// Restore restores the graphics state most recently saved with Save.
+func (p *Page) Restore() {
+	p.contents.op("Q")
+	p.saveDepth--
+	if p.saveDepth < 0 {
+		p.unbalanced = true
+	}
+	if n := len(p.stateStack); n > 0 {
+		s := p.stateStack[n-1]
+		p.state = s.drawState
+		p.currentFont, p.currentSize, p.leading, p.charSpacing = s.font, s.size, s.leading, s.charSpacing
+		p.stateStack = p.stateStack[:n-1]
+	}
+}
+
+// A savedState is an entry on the stack of states saved by Save. Since the
+// text settings are part of the PDF graphics state, restoring the state
+// brings back the font that was selected when it was saved (and clears the
+// current font if none was), so that it is selected again if needed.
+type savedState struct {
+	drawState
+	font        *Font
+	size        float64
+	leading     float64
+	charSpacing float64
+}
+
+// A drawState holds the parts of the graphics state that a Page keeps
+// track of, so that they can be read back.
+type drawState struct {
+	lineWidth float64
+	fill      Color
+	stroke    Color
+	dash      []float64
+	dashPhase float64
+}
+
+// initialState is the state at the beginning of each page.
+var initialState = drawState{
+	lineWidth: 1,
+	fill:      Gray(0),
+	stroke:    Gray(0),
+}
+
+// LineWidth returns the current line width, as set by SetLineWidth.
+func (p *Page) LineWidth() float64 {
+	return p.state.lineWidth
+}
+
+// FillColor returns the current fill color, as set by SetFillColor (or one
+// of the shortcuts like FillRGB).
+func (p *Page) FillColor() Color {
+	return p.state.fill
+}
+
+// StrokeColor returns the current stroke color, as set by SetStrokeColor
+// (or one of the shortcuts like StrokeRGB).
+func (p *Page) StrokeColor() Color {
+	return p.state.stroke
+}
+
+// Dash returns the current dash pattern and phase, as set by SetDash. The
+// pattern is empty for a solid line.
+func (p *Page) Dash() (pattern []float64, phase float64) {
+	return append([]float64(nil), p.state.dash...), p.state.dashPhase
+}
+
+// DrawGrid strokes a grid of thin lines across the whole page, spaced at
+// intervals of spacing, starting from the origin. It is intended as an aid
+// for positioning content during development. The graphics state is saved
+// and restored, so DrawGrid does not affect subsequent drawing.
+func (p *Page) DrawGrid(spacing float64, color Color) {
+	if spacing <= 0 {
+		return
+	}
+	p.Save()
+	p.SetStrokeColor(color)
+	p.SetLineWidth(0.25)
+	for x := 0.0; x <= p.width; x += spacing {
+		p.MoveTo(x, 0)
+		p.LineTo(x, p.height)
+	}
+	for y := 0.0; y <= p.height; y += spacing {
+		p.MoveTo(0, y)
+		p.LineTo(p.width, y)
+	}
+	p.Stroke()
+	p.Restore()
+}
+
+// A Rect is a rectangle in page coordinates, specified by its lower left
+// corner (X0, Y0) and its upper right corner (X1, Y1).
+type Rect struct {
+	X0, Y0, X1, Y1 float64
+}
+
+// RectXYWH returns the rectangle with its lower left corner at (x, y) and
+// the given width and height, the convention used by Rectangle and most
+// other drawing methods.
+func RectXYWH(x, y, width, height float64) Rect {
+	return RectCorners(x, y, x+width, y+height)
+}
+
+// RectCorners returns the rectangle with opposite corners at (x0, y0) and
+// (x1, y1), in either order.
+func RectCorners(x0, y0, x1, y1 float64) Rect {
+	if x1 < x0 {
+		x0, x1 = x1, x0
+	}
+	if y1 < y0 {
+		y0, y1 = y1, y0
+	}
+	return Rect{x0, y0, x1, y1}
+}
+
+// Width returns the width of r.
+func (r Rect) Width() float64 {
+	return r.X1 - r.X0
+}
+
+// Height returns the height of r.
+func (r Rect) Height() float64 {
+	return r.Y1 - r.Y0
+}
+
+// Contains reports whether (x, y) is inside r (including its edges).
+func (r Rect) Contains(x, y float64) bool {
+	return x >= r.X0 && x <= r.X1 && y >= r.Y0 && y <= r.Y1
+}
+
+// Inset returns r with each edge moved inward by d. If d is negative, the
+// edges are moved outward. If r is too small to be inset by d, the result
+// is an empty rectangle at r's center.
+func (r Rect) Inset(d float64) Rect {
+	if r.Width() < 2*d {
+		r.X0 = (r.X0 + r.X1) / 2
+		r.X1 = r.X0
+	} else {
+		r.X0 += d
+		r.X1 -= d
+	}
+	if r.Height() < 2*d {
+		r.Y0 = (r.Y0 + r.Y1) / 2
+		r.Y1 = r.Y0
+	} else {
+		r.Y0 += d
+		r.Y1 -= d
+	}
+	return r
+}
+
+// Union returns the smallest rectangle that contains both r and s.
+func (r Rect) Union(s Rect) Rect {
+	return Rect{
+		X0: math.Min(r.X0, s.X0),
+		Y0: math.Min(r.Y0, s.Y0),
+		X1: math.Max(r.X1, s.X1),
+		Y1: math.Max(r.Y1, s.Y1),
+	}
+}
+
+// String formats r as a PDF rectangle.
+func (r Rect) String() string {
+	return "[" + formatNum(r.X0) + " " + formatNum(r.Y0) + " " + formatNum(r.X1) + " " + formatNum(r.Y1) + "]"
+}
+
+// Rectangle adds a rectangle to the current path, as a complete subpath, with
+// its lower left corner at (x, y).
+func (p *Page) Rectangle(x, y, width, height float64) {
+	p.markPathStart()
+	p.contents.num(x, y, width, height)
+	p.contents.op("re")
+	p.currentX, p.currentY = x, y
+	p.startX, p.startY = x, y
+}
+
+// AddRect adds r to the current path, like Rectangle.
+func (p *Page) AddRect(r Rect) {
+	p.Rectangle(r.X0, r.Y0, r.Width(), r.Height())
+}
+
+// Clip intersects the clipping path with the current path, and ends the
+// path without painting it. Subsequent drawing is limited to the inside of
+// the path. The clipping path can't be enlarged again except by Restore, so
+// Clip is normally called after Save.
+func (p *Page) Clip() {
+	p.contents.op("W")
+	p.contents.op("n")
+}
+
+// ClipRect limits subsequent drawing to the rectangle with its lower left
+// corner at (x, y) and the given width and height (or to the part of it
+// inside the existing clipping path). It doesn't save the graphics state, so
+// the usual pattern is:
+//
+//	p.Save()
+//	p.ClipRect(x, y, width, height)
+//	// draw the clipped content
+//	p.Restore()
+func (p *Page) ClipRect(x, y, width, height float64) {
+	p.Rectangle(x, y, width, height)
+	p.Clip()
+}
+
+// Line strokes a straight line from (x1, y1) to (x2, y2).
+func (p *Page) Line(x1, y1, x2, y2 float64) {
+	p.MoveTo(x1, y1)
+	p.LineTo(x2, y2)
+	p.Stroke()
+}
+
+// HLine strokes a horizontal line from (x1, y) to (x2, y).
+func (p *Page) HLine(x1, x2, y float64) {
+	p.Line(x1, y, x2, y)
+}
+
+// VLine strokes a vertical line from (x, y1) to (x, y2).
+func (p *Page) VLine(x, y1, y2 float64) {
+	p.Line(x, y1, x, y2)
 }
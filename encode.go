@@ -2,7 +2,9 @@ package pdf
 
 import (
 	"bytes"
+	"crypto/md5"
 	"fmt"
+	"regexp"
 )
 
 type object interface {
@@ -15,10 +17,24 @@ type encoder struct {
 	objects []object
 	offsets []int
 	refs    map[object]int
+
+	asciiFilter          ASCIIFilter
+	compressionThreshold int
+	compression          Compression
+	pretty               bool
+	id                   [2][]byte
+	permanentID          []byte
+	version              string
+
+	// sigOffset is the offset of the DocMDP signature dictionary, or 0 if
+	// there is none.
+	sigOffset int
 }
 
 // getRef returns the 1-based index of o in e's list of objects. If v is not in
-// the list, it is added.
+// the list, it is added. Since o is only written when the encoder reaches it
+// in the list, it can be referenced before it is complete, as long as it is
+// finished by then.
 func (e *encoder) getRef(o object) int {
 	if ref, ok := e.refs[o]; ok {
 		return ref
@@ -35,16 +51,33 @@ func (e *encoder) encode(root object) []byte {
 	e.offsets = nil
 	e.refs = make(map[object]int)
 
-	e.WriteString("%PDF-1.7\n%öäüß\n")
+	fmt.Fprintf(e, "%%PDF-%s\n", e.version)
+	if e.asciiFilter == NoASCIIFilter {
+		// A comment with high-bit characters marks the file as binary.
+		e.WriteString("%öäüß\n")
+	}
 	rootRef := e.getRef(root)
 
 	for i := 0; i < len(e.objects); i++ {
 		e.offsets = append(e.offsets, e.Len())
 		fmt.Fprintf(e, "%d 0 obj\n", i+1)
+		start := e.Len()
 		e.objects[i].writeTo(e)
+		if e.pretty {
+			e.prettify(start)
+		}
 		e.WriteString("\nendobj\n")
 	}
 
+	id := e.id
+	if id[0] == nil {
+		sum := md5.Sum(e.Bytes())
+		if e.permanentID == nil {
+			e.permanentID = sum[:]
+		}
+		id = [2][]byte{e.permanentID, sum[:]}
+	}
+
 	startxref := e.Len()
 	e.WriteString("xref\n")
 	fmt.Fprintf(e, "0 %d\n", len(e.objects)+1)
@@ -54,10 +87,31 @@ func (e *encoder) encode(root object) []byte {
 	}
 
 	e.WriteString("trailer\n")
-	fmt.Fprintf(e, "<< /Root %d 0 R /Size %d >>\n", rootRef, len(e.objects)+1)
+	fmt.Fprintf(e, "<< /Root %d 0 R /Size %d /ID [<%x> <%x>] >>\n", rootRef, len(e.objects)+1, id[0], id[1])
 	e.WriteString("startxref\n")
 	fmt.Fprintln(e, startxref)
 	e.WriteString("%%EOF\n")
 
 	return e.Bytes()
 }
+
+// streamKeyword matches the keyword that begins the data of a stream
+// object.
+var streamKeyword = regexp.MustCompile(`[\s>](stream\r?\n)`)
+
+// prettify reformats the object that was written starting at offset start,
+// for SetPretty.
+func (e *encoder) prettify(start int) {
+	obj := append([]byte(nil), e.Bytes()[start:]...)
+	head, rest := obj, []byte(nil)
+	if m := streamKeyword.FindSubmatchIndex(obj); m != nil {
+		// Raw objects may have a different line ending after the stream
+		// keyword, or none before it; the data that follows is kept as
+		// it is.
+		head = bytes.TrimRight(obj[:m[2]], " \t\r\n")
+		rest = append([]byte("\n"), obj[m[2]:]...)
+	}
+	e.Truncate(start)
+	e.Write(prettyObject(head))
+	e.Write(rest)
+}
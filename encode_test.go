@@ -0,0 +1,256 @@
+package pdf
+
+import (
+	"bytes"
+	"image"
+	"image/color"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+// buildTestDocument makes a document that uses many kinds of resources and
+// document-level features, each stored in a map somewhere along the way.
+func buildTestDocument(t *testing.T) *Document {
+	t.Helper()
+	regular, err := ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	bold, err := ParseFont(gobold.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	img := image.NewRGBA(image.Rect(0, 0, 4, 4))
+	for i := range img.Pix {
+		img.Pix[i] = byte(i * 7)
+	}
+	gray := image.NewGray(image.Rect(0, 0, 2, 2))
+	gray.SetGray(1, 1, color.Gray{200})
+
+	d := new(Document)
+	d.EnableAutoTagging()
+	d.SetPageNumbering("Page {page} of {pages}", regular, 9, 306, 36, AlignCenter)
+	tmpl := NewTemplate(50, 20)
+	tmpl.SetFont(bold, 10)
+	tmpl.Left(2, 5, "Template")
+	spot := NewSeparation("Spot", CMYK{0, 0.5, 1, 0})
+	layer := d.NewLayer("Notes", LayerScreenOnly)
+	stamp := d.NewStamp("Approved", 80, 30)
+	stamp.SetFont(bold, 14)
+	stamp.Left(4, 8, "APPROVED")
+
+	var pages []*Page
+	for i := 0; i < 3; i++ {
+		p := d.NewPage(612, 792)
+		pages = append(pages, p)
+		p.SetFont(regular, 12)
+		p.Left(72, 700, "Regular text")
+		p.SetFont(bold, 12)
+		p.Left(72, 680, "Bold text")
+		p.DrawImage(NewImage(img), 72, 500, 100, 100)
+		p.DrawImage(NewImage(gray), 200, 500, 50, 50)
+		p.DrawTemplate(tmpl, 300, 500)
+		p.SetBlendMode("Multiply")
+		p.Rectangle(72, 300, 100, 100)
+		p.FillGradient(NewLinearGradient(72, 300, 172, 300, RGB{1, 0, 0}, RGB{0, 0, 1}))
+		p.SetFillColor(spot.Color(0.5))
+		p.Rectangle(200, 300, 50, 50)
+		p.Fill()
+		p.BeginLayer(layer)
+		p.Left(72, 200, "On a layer")
+		p.EndLayer()
+		p.PlaceStamp(stamp, 400, 100)
+		d.AddDestination(string(rune('c'-i))+"-dest", p, 700)
+	}
+	pages[0].LinkTo(RectXYWH(72, 680, 100, 20), Destination{Page: pages[2], Mode: Fit})
+	b := d.AddBookmark("First", Destination{Page: pages[0], Mode: Fit})
+	b.AddChild("Second", Destination{Page: pages[1], Mode: FitH, Top: 700})
+	d.AttachFile("a.txt", []byte("attachment a"))
+	d.AttachFile("b.txt", []byte("attachment b"))
+	return d
+}
+
+func TestEncodeDeterministic(t *testing.T) {
+	d := buildTestDocument(t)
+	first := d.Encode()
+	if second := d.Encode(); !bytes.Equal(first, second) {
+		t.Error("encoding the same document twice gave different output")
+	}
+	// A separate but identical document has different pointers as map
+	// keys, so it would show up differences in map iteration order.
+	if other := buildTestDocument(t).Encode(); !bytes.Equal(first, other) {
+		t.Error("encoding identical documents gave different output")
+	}
+}
+
+func TestPrettyRawStream(t *testing.T) {
+	// The stream data looks like PDF syntax, so it would be reformatted if
+	// it weren't recognized as stream data.
+	const data = "<</A(x)>>[1   2]\r\n\x00\xff"
+	for _, body := range []string{
+		"<< /Length 20   /Foo   [1  2] >>stream\r\n" + data + "\r\nendstream",
+		"<< /Length 20   /Foo   [1  2] >>\r\nstream\r\n" + data + "\nendstream",
+		"<< /Length 20   /Foo   [1  2] >>\nstream\n" + data + "\nendstream",
+	} {
+		d := new(Document)
+		d.SetPretty(true)
+		d.NewPage(612, 792)
+		d.AddRawObject([]byte(body))
+		out := d.Encode()
+		if !bytes.Contains(out, []byte("stream\r\n"+data)) && !bytes.Contains(out, []byte("stream\n"+data)) {
+			t.Errorf("stream data of %q changed in pretty mode", body)
+		}
+		if !bytes.Contains(out, []byte("  /Foo [1 2]\n>>\nstream")) {
+			t.Errorf("dictionary of %q not reformatted", body)
+		}
+	}
+}
+
+// trailerID returns the two parts of the file identifier in a PDF file's
+// trailer, in hex.
+func trailerID(t *testing.T, b []byte) (string, string) {
+	t.Helper()
+	m := regexp.MustCompile(`/ID \[<([0-9a-f]*)> <([0-9a-f]*)>\]`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("no /ID in trailer")
+	}
+	return string(m[1]), string(m[2])
+}
+
+func TestEncodeID(t *testing.T) {
+	d := new(Document)
+	d.NewPage(612, 792)
+	first, second := trailerID(t, d.Encode())
+	if first != second {
+		t.Errorf("new document has ID parts %s and %s, want them equal", first, second)
+	}
+
+	d.NewPage(612, 792)
+	first2, second2 := trailerID(t, d.Encode())
+	if first2 != first {
+		t.Errorf("first ID part changed from %s to %s after modifying the document", first, first2)
+	}
+	if second2 == second {
+		t.Error("second ID part unchanged after modifying the document")
+	}
+	if _, again := trailerID(t, d.Encode()); again != second2 {
+		t.Errorf("second ID part changed from %s to %s without modifying the document", second2, again)
+	}
+
+	d.SetID([2][]byte{{1, 2}, {3, 4}})
+	if first, second := trailerID(t, d.Encode()); first != "0102" || second != "0304" {
+		t.Errorf("SetID gave ID parts %s and %s, want 0102 and 0304", first, second)
+	}
+}
+
+func TestNameTreeOrder(t *testing.T) {
+	// Non-ASCII keys are written in UTF-16BE with a byte order mark, so
+	// they sort after all the ASCII ones.
+	tree := nameTree([]string{"é", "b", "Ā", "a", "z"}, func(key string) string { return "null" })
+	want := "<< /Names [(a) null (b) null (z) null <FEFF00E9> null <FEFF0100> null ] >>"
+	if tree != want {
+		t.Errorf("got %s, want %s", tree, want)
+	}
+}
+
+func TestDocMDP(t *testing.T) {
+	d := new(Document)
+	first := d.NewPage(612, 792)
+	d.NewPage(612, 792)
+	// A string that looks like the ByteRange placeholder, in an object
+	// that is written before the signature dictionary.
+	d.AddRawObject([]byte("(" + byteRangePlaceholder + " /Contents <00>)"))
+	d.SetDocMDP(MDPNoChanges)
+	d.Encode()
+	d.MovePage(0, 1)
+	b := d.Encode()
+
+	if first.sigField != nil {
+		t.Error("page that is no longer first still has the signature field")
+	}
+	if n := bytes.Count(b, []byte("/Subtype /Widget")); n != 1 {
+		t.Errorf("%d signature widgets, want 1", n)
+	}
+
+	m := regexp.MustCompile(`/ByteRange \[0 (\d+) (\d+) (\d+) *\]`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("ByteRange not filled in")
+	}
+	start, _ := strconv.Atoi(string(m[1]))
+	end, _ := strconv.Atoi(string(m[2]))
+	rest, _ := strconv.Atoi(string(m[3]))
+	if end+rest != len(b) {
+		t.Errorf("ByteRange ends at %d, but the file is %d bytes", end+rest, len(b))
+	}
+	if want := "<" + strings.Repeat("0", 2*sigContentsSize) + ">"; string(b[start:end]) != want {
+		t.Errorf("ByteRange gap doesn't cover the signature contents: %.40q", b[start:end])
+	}
+}
+
+func TestReserveObject(t *testing.T) {
+	d := new(Document)
+	d.NewPage(612, 792)
+	count := d.ReserveObject()
+	d.AddRawObject([]byte("<< /PageCount {{0}} >>"), count)
+	if errs := d.Validate(); len(errs) != 1 || errs[0].Error() != "raw object 1 was reserved but never defined with DefineObject" {
+		t.Errorf("got errors %v for an undefined object", errs)
+	}
+
+	d.NewPage(612, 792)
+	d.DefineObject(count, []byte(strconv.Itoa(d.PageCount())))
+	if errs := d.Validate(); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+	b := d.Encode()
+	m := regexp.MustCompile(`<< /PageCount (\d+) 0 R >>`).FindSubmatch(b)
+	if m == nil {
+		t.Fatal("reference to the reserved object not written")
+	}
+	if !bytes.Contains(b, []byte("\n"+string(m[1])+" 0 obj\n2\nendobj")) {
+		t.Errorf("reserved object %s not written with its definition", m[1])
+	}
+}
+
+func TestPatternMatrixNotExponential(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	img := NewImage(image.NewGray(image.Rect(0, 0, 2, 2)))
+	// In floating point, 0.1 + 0.2 - 0.3 is a tiny positive number, not 0.
+	a, b := 0.1, 0.2
+	x := a + b - 0.3
+	p.DrawImageTiled(img, x, -x, 100, 100, 10, 10)
+	out := d.Encode()
+	if !bytes.Contains(out, []byte("/Matrix [1 0 0 1 0 0]")) {
+		m := regexp.MustCompile(`/Matrix \[[^]]*\]`).Find(out)
+		t.Errorf("got %s, want /Matrix [1 0 0 1 0 0]", m)
+	}
+}
+
+func TestSeparationNotExponential(t *testing.T) {
+	a, b := 0.1, 0.2
+	tiny := a + b - 0.3
+	e := &encoder{}
+	NewSeparation("Spot", CMYK{tiny, 0.5, 1, 0.25}).writeTo(e)
+	if got := e.String(); !strings.Contains(got, "/C1 [0 0.5 1 0.25]") {
+		t.Errorf("got %s, want /C1 [0 0.5 1 0.25]", got)
+	}
+}
+
+func TestCloneGetsNewID(t *testing.T) {
+	d := new(Document)
+	d.NewPage(612, 792)
+	first, _ := trailerID(t, d.Encode())
+	c := d.Clone()
+	c.NewPage(612, 792)
+	if cloneFirst, _ := trailerID(t, c.Encode()); cloneFirst == first {
+		t.Error("clone has the same permanent ID as the original")
+	}
+	if again, _ := trailerID(t, d.Encode()); again != first {
+		t.Error("original's permanent ID changed after cloning")
+	}
+}
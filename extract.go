@@ -0,0 +1,303 @@
+package pdf
+
+import (
+	"encoding/hex"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"unicode/utf16"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// ExtractText reads back the text from the document's content streams, the
+// way a PDF viewer would for copying or searching: character codes are
+// converted to text with the ToUnicode CMaps the package writes for its
+// fonts, not with the fonts' own mappings, so mistakes in the CMaps show up
+// in the result. Line breaks are inserted where the text moves to a new
+// line, and spaces where it skips forward on the same line. Pages are
+// separated by form feeds.
+//
+// It understands only the content that this package produces. Headers and
+// footers are included only after the document has been encoded.
+func (d *Document) ExtractText() (string, error) {
+	var pages []string
+	for i, p := range d.pages.pages {
+		x := &textExtractor{cmaps: make(map[*Font]map[byte]string)}
+		if err := x.extract(p, p.pageContent()); err != nil {
+			return "", fmt.Errorf("page %d: %v", i+1, err)
+		}
+		pages = append(pages, x.b.String())
+	}
+	return strings.Join(pages, "\f"), nil
+}
+
+// A textExtractor reconstructs the text from content streams.
+type textExtractor struct {
+	b     strings.Builder
+	cmaps map[*Font]map[byte]string
+
+	textState
+	stack   []textState
+	tm, tlm [6]float64
+
+	// started is set once any text has been written; endX and endY are
+	// where the last text ended, in user space.
+	started    bool
+	endX, endY float64
+}
+
+// A textState holds the text state parameters, which are saved and
+// restored with the rest of the graphics state by q and Q.
+type textState struct {
+	font        *Font
+	size        float64
+	leading     float64
+	charSpacing float64
+	wordSpacing float64
+}
+
+// extract adds the text from content, which is drawn on p (or on a
+// Template), to x.b.
+func (x *textExtractor) extract(p *Page, content []byte) error {
+	var operands []string
+	for _, tok := range pdfTokens(content) {
+		if !isOperator(tok) {
+			operands = append(operands, tok)
+			continue
+		}
+		nums := func() []float64 {
+			v := make([]float64, len(operands))
+			for i, s := range operands {
+				v[i], _ = strconv.ParseFloat(s, 64)
+			}
+			return v
+		}
+
+		switch tok {
+		case "q":
+			x.stack = append(x.stack, x.textState)
+		case "Q":
+			if n := len(x.stack); n > 0 {
+				x.textState = x.stack[n-1]
+				x.stack = x.stack[:n-1]
+			}
+		case "BT":
+			x.tm, x.tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(operands) != 2 {
+				break
+			}
+			x.font = nil
+			for f, id := range p.fonts {
+				if operands[0] == fmt.Sprintf("/F%d", id) {
+					x.font = f
+				}
+			}
+			if x.font == nil {
+				return fmt.Errorf("unknown font %s", operands[0])
+			}
+			x.size, _ = strconv.ParseFloat(operands[1], 64)
+		case "TL":
+			if v := nums(); len(v) == 1 {
+				x.leading = v[0]
+			}
+		case "Tc":
+			if v := nums(); len(v) == 1 {
+				x.charSpacing = v[0]
+			}
+		case "Tm":
+			if v := nums(); len(v) == 6 {
+				copy(x.tlm[:], v)
+				x.tm = x.tlm
+			}
+		case "Td", "TD":
+			if v := nums(); len(v) == 2 {
+				x.moveLine(v[0], v[1])
+				if tok == "TD" {
+					x.leading = -v[1]
+				}
+			}
+		case "T*":
+			x.moveLine(0, -x.leading)
+		case "Tj", "'", "\"":
+			if tok != "Tj" {
+				x.moveLine(0, -x.leading)
+			}
+			if len(operands) > 0 {
+				if err := x.show(operands[len(operands)-1:]); err != nil {
+					return err
+				}
+			}
+		case "TJ":
+			if len(operands) >= 2 {
+				if err := x.show(operands[1 : len(operands)-1]); err != nil {
+					return err
+				}
+			}
+		case "Do":
+			for t, id := range p.templates {
+				if len(operands) == 1 && operands[0] == fmt.Sprintf("/Tp%d", id) {
+					// A template is drawn with its own copy of the
+					// graphics state.
+					saved := x.textState
+					if err := x.extract(t.Page, t.pageContent()); err != nil {
+						return err
+					}
+					x.textState = saved
+				}
+			}
+		}
+		operands = operands[:0]
+	}
+	return nil
+}
+
+// moveLine moves to the start of a new line, offset by (dx, dy) from the
+// start of the current one.
+func (x *textExtractor) moveLine(dx, dy float64) {
+	m := &x.tlm
+	m[4] += dx*m[0] + dy*m[2]
+	m[5] += dx*m[1] + dy*m[3]
+	x.tm = x.tlm
+}
+
+// show adds the text shown by the operands of TJ (or Tj) to the output.
+func (x *textExtractor) show(items []string) error {
+	if x.font == nil {
+		return fmt.Errorf("text shown with no font selected")
+	}
+	cmap, ok := x.cmaps[x.font]
+	if !ok {
+		var err error
+		cmap, err = parseToUnicode(toUnicodeCMap(x.font.unicodeText()).b.Bytes())
+		if err != nil {
+			return err
+		}
+		x.cmaps[x.font] = cmap
+	}
+
+	// Start a new line or a new word if the text doesn't continue from
+	// where the previous text ended.
+	m := &x.tm
+	if x.started {
+		switch {
+		case math.Abs(m[5]-x.endY) > x.size/2:
+			x.b.WriteByte('\n')
+		case m[4]-x.endX > x.size/5:
+			x.b.WriteByte(' ')
+		}
+	}
+
+	var buffer sfnt.Buffer
+	for _, item := range items {
+		if item[0] != '(' {
+			n, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				continue
+			}
+			// A large negative adjustment is a gap between words.
+			if n < -200 {
+				x.b.WriteByte(' ')
+			}
+			m[4] -= n / 1000 * x.size * m[0]
+			m[5] -= n / 1000 * x.size * m[1]
+			continue
+		}
+		for _, c := range []byte(unquoteString(item)) {
+			text, ok := cmap[c]
+			if !ok {
+				return fmt.Errorf("character code %d is not in the ToUnicode CMap", c)
+			}
+			x.b.WriteString(text)
+			advance := x.charSpacing + float64(x.font.codeAdvance(&buffer, c))/1000*x.size
+			m[4] += advance * m[0]
+			m[5] += advance * m[1]
+		}
+	}
+	x.started = true
+	x.endX, x.endY = m[4], m[5]
+	return nil
+}
+
+// parseToUnicode reads the mappings from a ToUnicode CMap with single-byte
+// codes.
+func parseToUnicode(data []byte) (map[byte]string, error) {
+	hexValue := func(tok string) ([]byte, error) {
+		if len(tok) < 2 || tok[0] != '<' || tok[len(tok)-1] != '>' {
+			return nil, fmt.Errorf("invalid CMap entry %q", tok)
+		}
+		return hex.DecodeString(tok[1 : len(tok)-1])
+	}
+	utf16Text := func(b []byte) string {
+		u := make([]uint16, len(b)/2)
+		for i := range u {
+			u[i] = uint16(b[2*i])<<8 | uint16(b[2*i+1])
+		}
+		return string(utf16.Decode(u))
+	}
+
+	result := make(map[byte]string)
+	tokens := pdfTokens(data)
+	section := ""
+	for i := 0; i < len(tokens); i++ {
+		switch tok := tokens[i]; tok {
+		case "beginbfchar", "beginbfrange":
+			section = tok
+			continue
+		case "endbfchar", "endbfrange":
+			section = ""
+			continue
+		}
+
+		switch section {
+		case "beginbfchar":
+			if i+1 >= len(tokens) {
+				return nil, fmt.Errorf("truncated bfchar section")
+			}
+			src, err := hexValue(tokens[i])
+			if err != nil {
+				return nil, err
+			}
+			dst, err := hexValue(tokens[i+1])
+			if err != nil {
+				return nil, err
+			}
+			if len(src) != 1 {
+				return nil, fmt.Errorf("invalid source code %s", tokens[i])
+			}
+			result[src[0]] = utf16Text(dst)
+			i++
+		case "beginbfrange":
+			if i+2 >= len(tokens) {
+				return nil, fmt.Errorf("truncated bfrange section")
+			}
+			lo, err := hexValue(tokens[i])
+			if err != nil {
+				return nil, err
+			}
+			hi, err := hexValue(tokens[i+1])
+			if err != nil {
+				return nil, err
+			}
+			dst, err := hexValue(tokens[i+2])
+			if err != nil {
+				return nil, err
+			}
+			if len(lo) != 1 || len(hi) != 1 || len(dst) < 2 {
+				return nil, fmt.Errorf("invalid bfrange %s %s %s", tokens[i], tokens[i+1], tokens[i+2])
+			}
+			for c := int(lo[0]); c <= int(hi[0]); c++ {
+				result[byte(c)] = utf16Text(dst)
+				// The last UTF-16 code unit is incremented for each code.
+				n := len(dst)
+				if dst[n-1]++; dst[n-1] == 0 {
+					dst[n-2]++
+				}
+			}
+			i += 2
+		}
+	}
+	return result, nil
+}
@@ -0,0 +1,89 @@
+package pdf
+
+import (
+	"testing"
+
+	"golang.org/x/image/font/gofont/gobold"
+)
+
+// extracted returns the text extracted from d, failing the test on an error.
+func extracted(t *testing.T, d *Document) string {
+	t.Helper()
+	text, err := d.ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return text
+}
+
+func TestExtractText(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	p.SetLeading(14)
+	p.Left(72, 700, "Hello,")
+	p.Left(120, 700, "world")
+	p.WordWrap(72, 650, 60, "Ünïcode text wraps here")
+	p = d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	p.Left(72, 700, "Second page")
+
+	want := "Hello, world\nÜnïcode\ntext wraps\nhere\fSecond page"
+	if got := extracted(t, d); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtractTextBeyondBMP(t *testing.T) {
+	// Characters outside the Basic Multilingual Plane are written to the
+	// ToUnicode CMap as UTF-16 surrogate pairs.
+	const s = "a 😀 b 𝄞"
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(testFont(t), 12)
+	p.Left(72, 700, s)
+	if got := extracted(t, d); got != s {
+		t.Errorf("got %q, want %q", got, s)
+	}
+}
+
+func TestExtractTextSaveRestore(t *testing.T) {
+	// The two fonts assign the same character codes to different
+	// characters, so the text comes out wrong if the font isn't restored
+	// after Q.
+	regular := testFont(t)
+	bold, err := ParseFont(gobold.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(regular, 12)
+	p.Left(72, 700, "αβγ")
+	p.Save()
+	p.SetFont(bold, 12)
+	p.Left(72, 680, "ΩΨΦ")
+	p.Restore()
+	p.Left(72, 660, "αβγ")
+
+	want := "αβγ\nΩΨΦ\nαβγ"
+	if got := extracted(t, d); got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
+
+func TestExtractTextTemplate(t *testing.T) {
+	f := testFont(t)
+	tmpl := NewTemplate(100, 20)
+	tmpl.SetFont(f, 10)
+	tmpl.Left(0, 5, "In template")
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	p.Left(72, 700, "Before")
+	p.DrawTemplate(tmpl, 72, 600)
+	if got, want := extracted(t, d), "Before\nIn template"; got != want {
+		t.Errorf("got %q, want %q", got, want)
+	}
+}
@@ -0,0 +1,287 @@
+package pdf
+
+// A Flow lays out content from the top to the bottom of the content box of
+// each page (see Page.ContentBox), starting new pages as needed.
+type Flow struct {
+	doc           *Document
+	width, height float64
+
+	page *Page
+	// top is the y coordinate of the top of the space remaining on page.
+	top float64
+
+	// BodyFont, BodySize, and BodyLeading are used for paragraphs and
+	// tables.
+	BodyFont    *Font
+	BodySize    float64
+	BodyLeading float64
+
+	// HeadingFont, HeadingSize, and HeadingLeading are used for headings.
+	HeadingFont    *Font
+	HeadingSize    float64
+	HeadingLeading float64
+
+	// BodyColor and HeadingColor, if they are not nil, are the colors of
+	// the text of paragraphs and tables, and of headings.
+	BodyColor    Color
+	HeadingColor Color
+
+	// BodyAlignment and HeadingAlignment position each line of a
+	// paragraph or heading within the width of the content box.
+	BodyAlignment    Alignment
+	HeadingAlignment Alignment
+
+	// ParaStyle controls how paragraphs and headings are broken across
+	// pages.
+	ParaStyle ParaStyle
+
+	// Spacing is the amount of vertical space left after each paragraph,
+	// heading, table, or image.
+	Spacing float64
+
+	// NewPageFunc, if it is not nil, is called for each new page the Flow
+	// creates, before any content is placed on it. It can be used to draw
+	// running headers and footers.
+	NewPageFunc func(p *Page)
+}
+
+// NewFlow returns a Flow that adds pages of the given size to d.
+func NewFlow(d *Document, width, height float64) *Flow {
+	return &Flow{
+		doc:       d,
+		width:     width,
+		height:    height,
+		ParaStyle: ParaStyle{MinOrphanLines: 2, MinWidowLines: 2, KeepWithNext: 2},
+	}
+}
+
+// Page returns the page that content is currently being added to, starting
+// the first page if necessary.
+func (f *Flow) Page() *Page {
+	if f.page == nil {
+		f.PageBreak()
+	}
+	return f.page
+}
+
+// PageBreak starts a new page.
+func (f *Flow) PageBreak() {
+	f.page = f.doc.NewPage(f.width, f.height)
+	if f.NewPageFunc != nil {
+		f.page.Save()
+		f.NewPageFunc(f.page)
+		f.page.Restore()
+	}
+	_, y, _, height := f.page.ContentBox()
+	f.top = y + height
+}
+
+// atTop reports whether nothing has been placed on the current page yet.
+func (f *Flow) atTop() bool {
+	_, y, _, height := f.page.ContentBox()
+	return f.top == y+height
+}
+
+// Spacer leaves height units of vertical space. If there is not that much
+// space left on the page, it starts a new page instead.
+func (f *Flow) Spacer(height float64) {
+	p := f.Page()
+	_, bottom, _, _ := p.ContentBox()
+	if f.top-height < bottom {
+		f.PageBreak()
+		return
+	}
+	f.top -= height
+}
+
+// EnsureSpace starts a new page if there is less than height units of
+// vertical space left on the current one, and reports whether it did. It is
+// used before a block that shouldn't be split or left stranded at the
+// bottom of a page, such as a heading followed by a small table. Nothing is
+// done if the current page is still empty.
+func (f *Flow) EnsureSpace(height float64) bool {
+	p := f.Page()
+	_, bottom, _, _ := p.ContentBox()
+	if f.top-height >= bottom || f.atTop() {
+		return false
+	}
+	f.PageBreak()
+	return true
+}
+
+// WriteParagraph adds a word-wrapped paragraph, continuing it on new pages as
+// needed. At least one line is put on each page, even if the content box is
+// too short for it; in that case the line extends below the bottom margin,
+// and Validate reports it.
+func (f *Flow) WriteParagraph(s string) {
+	for {
+		p := f.Page()
+		x, bottom, width, _ := p.ContentBox()
+		p.SetFont(f.BodyFont, f.BodySize)
+		p.SetLeading(f.BodyLeading)
+		ascent, descent := f.BodyFont.metrics()
+		baseline := f.top - float64(ascent)*0.001*f.BodySize
+		lowest := bottom + float64(descent)*0.001*f.BodySize
+		style := f.ParaStyle
+		if f.atTop() {
+			// Draw as much as fits, and at least one line, to avoid an
+			// infinite loop.
+			style = ParaStyle{LineBreaking: style.LineBreaking}
+			if baseline < lowest {
+				p.problems = append(p.problems, "paragraph text extends below the bottom margin, because the content box is too short for a line")
+				lowest = baseline
+			}
+		}
+		var rest string
+		var nextY float64
+		withColor(p, f.BodyColor, func() {
+			rest, nextY = p.paragraph(x, baseline, width, lowest, s, style, "P", f.BodyAlignment)
+		})
+		f.top = nextY + float64(ascent)*0.001*f.BodySize
+		if rest == "" {
+			break
+		}
+		s = rest
+		f.PageBreak()
+	}
+	f.Spacer(f.Spacing)
+}
+
+// WriteHeading adds a heading. If the heading won't fit on the current page
+// along with the first lines of the following paragraph (as specified by
+// ParaStyle.KeepWithNext), it starts a new page.
+func (f *Flow) WriteHeading(s string) {
+	p := f.Page()
+	x, bottom, width, _ := p.ContentBox()
+	p.SetFont(f.HeadingFont, f.HeadingSize)
+	p.SetLeading(f.HeadingLeading)
+	ascent, _ := f.HeadingFont.metrics()
+	_, bodyDescent := f.BodyFont.metrics()
+	baseline := f.top - float64(ascent)*0.001*f.HeadingSize
+	var nextY float64
+	var ok bool
+	withColor(p, f.HeadingColor, func() {
+		nextY, ok = p.heading(x, baseline, width, bottom+float64(bodyDescent)*0.001*f.BodySize, s, f.ParaStyle, f.BodyLeading, f.HeadingAlignment)
+		if !ok && f.atTop() {
+			_, nextY = p.paragraph(x, baseline, width, bottom, s, ParaStyle{LineBreaking: f.ParaStyle.LineBreaking}, "H", f.HeadingAlignment)
+		}
+	})
+	if !ok && !f.atTop() {
+		f.PageBreak()
+		f.WriteHeading(s)
+		return
+	}
+	f.top = nextY + float64(ascent)*0.001*f.HeadingSize
+	f.Spacer(f.Spacing)
+}
+
+// Image adds img, scaled to width and centered horizontally, with its
+// aspect ratio preserved. If it doesn't fit on the current page, it starts a
+// new page.
+func (f *Flow) Image(img *Image, width float64) {
+	p := f.Page()
+	x, bottom, boxWidth, _ := p.ContentBox()
+	height := width * float64(img.height) / float64(img.width)
+	if f.top-height < bottom && !f.atTop() {
+		f.PageBreak()
+		p = f.page
+	}
+	f.top -= height
+	p.DrawImage(img, x+(boxWidth-width)/2, f.top, width, height)
+	f.Spacer(f.Spacing)
+}
+
+// WriteTable adds a table, with columns of the given widths. The first row is
+// treated as a header row; it is repeated at the top of each page if the
+// table is continued on more than one page. Text in cells is word-wrapped.
+//
+// With automatic tagging, the table is tagged as a Table element, with a TR
+// element for each row, and TH (with column scope) or TD elements for the
+// cells. The header rows repeated on later pages are marked as artifacts.
+func (f *Flow) WriteTable(rows [][]string, columnWidths []float64) {
+	if len(rows) == 0 {
+		return
+	}
+	const padding = 2
+	f.Page().beginGroup("Table", "")
+	for i, row := range rows {
+		p := f.Page()
+		_, bottom, _, _ := p.ContentBox()
+		height := f.rowHeight(row, columnWidths, padding)
+		if f.top-height < bottom && !f.atTop() {
+			f.PageBreak()
+			if i > 0 {
+				f.page.beginArtifact()
+				f.drawRow(rows[0], columnWidths, padding, true)
+				f.page.endArtifact()
+			}
+		}
+		f.drawRow(row, columnWidths, padding, i == 0)
+	}
+	f.page.endGroup()
+	f.Spacer(f.Spacing)
+}
+
+// rowHeight returns the height of a table row.
+func (f *Flow) rowHeight(row []string, columnWidths []float64, padding float64) float64 {
+	lines := 1
+	for i, cell := range row {
+		if i >= len(columnWidths) {
+			break
+		}
+		n := len(f.BodyFont.wrapLines(cell, int((columnWidths[i]-2*padding)/f.BodySize*1000)))
+		if n > lines {
+			lines = n
+		}
+	}
+	return float64(lines)*f.BodyLeading + 2*padding
+}
+
+// drawRow draws a table row at the top of the remaining space. If rule is
+// true, a line is drawn under it.
+func (f *Flow) drawRow(row []string, columnWidths []float64, padding float64, rule bool) {
+	p := f.page
+	x, _, _, _ := p.ContentBox()
+	height := f.rowHeight(row, columnWidths, padding)
+	p.SetFont(f.BodyFont, f.BodySize)
+	p.SetLeading(f.BodyLeading)
+	ascent, _ := f.BodyFont.metrics()
+	baseline := f.top - padding - float64(ascent)*0.001*f.BodySize
+	cellX := x
+	p.beginGroup("TR", "")
+	for i, cell := range row {
+		if i >= len(columnWidths) {
+			break
+		}
+		if rule {
+			p.beginGroup("TH", "<< /O /Table /Scope /Column >>")
+		} else {
+			p.beginGroup("TD", "")
+		}
+		withColor(p, f.BodyColor, func() {
+			p.WordWrap(cellX+padding, baseline, columnWidths[i]-2*padding, cell)
+		})
+		p.endGroup()
+		cellX += columnWidths[i]
+	}
+	p.endGroup()
+	f.top -= height
+	if rule {
+		p.MoveTo(x, f.top)
+		p.LineTo(cellX, f.top)
+		p.Stroke()
+	}
+}
+
+// withColor calls draw with the fill color set to c, and restores the
+// previous color afterward. If c is nil, it just calls draw.
+func withColor(p *Page, c Color, draw func()) {
+	if c == nil {
+		draw()
+		return
+	}
+	p.Save()
+	p.SetFillColor(c)
+	draw()
+	p.Restore()
+}
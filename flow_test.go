@@ -0,0 +1,56 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func testFlow(t *testing.T, d *Document) *Flow {
+	t.Helper()
+	fl := NewFlow(d, 612, 792)
+	fl.BodyFont = testFont(t)
+	fl.BodySize = 12
+	fl.BodyLeading = 14
+	return fl
+}
+
+func TestWriteParagraphShortContentBox(t *testing.T) {
+	// The content box is only 5 points high, too short for a line of
+	// 12-point text.
+	d := new(Document)
+	d.SetMargins(Margins{Top: 400, Bottom: 387, Inner: 72, Outer: 72})
+	fl := testFlow(t, d)
+	const s = "Every word of this paragraph should still be in the document, even though no line fits on a page."
+	fl.WriteParagraph(s)
+
+	text := strings.Join(strings.Fields(strings.Replace(extracted(t, d), "\f", " ", -1)), " ")
+	if text != s {
+		t.Errorf("got %q, want %q", text, s)
+	}
+	if d.PageCount() < 2 {
+		t.Errorf("paragraph put on %d page(s), want one line per page", d.PageCount())
+	}
+	if len(d.Validate()) == 0 {
+		t.Error("Validate didn't report the text below the bottom margin")
+	}
+}
+
+func TestWriteParagraphAcrossPages(t *testing.T) {
+	d := new(Document)
+	d.SetMargins(Margins{Top: 72, Bottom: 72, Inner: 72, Outer: 72})
+	fl := testFlow(t, d)
+	s := strings.Repeat("The quick brown fox jumps over the lazy dog. ", 200)
+	s = strings.TrimSpace(s)
+	fl.WriteParagraph(s)
+
+	text := strings.Join(strings.Fields(strings.Replace(extracted(t, d), "\f", " ", -1)), " ")
+	if text != s {
+		t.Error("text was lost or changed when the paragraph was continued on new pages")
+	}
+	if d.PageCount() < 2 {
+		t.Errorf("got %d page(s), want the paragraph continued on more", d.PageCount())
+	}
+	if errs := d.Validate(); len(errs) != 0 {
+		t.Errorf("Validate returned %v", errs)
+	}
+}
@@ -4,9 +4,11 @@ import (
 	"fmt"
 	"io/ioutil"
 	"log"
+	"math"
 	"sort"
 	"strconv"
 	"strings"
+	"unicode"
 
 	"golang.org/x/image/font"
 	"golang.org/x/image/font/sfnt"
@@ -19,6 +21,82 @@ type Font struct {
 
 	encode    map[rune]byte
 	toUnicode [256]rune
+
+	// dropped holds the runes that could not be displayed because all 255
+	// character codes were already in use.
+	dropped runeSet
+
+	// fixedAdvance is the advance width (in 1/1000 em) used for every
+	// character if the font is being used in monospaced mode.
+	fixedAdvance int
+
+	// hinting is the hinting mode used for all metrics.
+	hinting font.Hinting
+
+	// outlines is set if text is drawn as glyph outlines in the content
+	// stream, and the font's own glyphs are left empty.
+	outlines bool
+
+	// missingPolicy and replacement control what happens to characters the
+	// font has no glyphs for; missing holds the characters that were left
+	// out under MissingGlyphError.
+	missingPolicy MissingGlyphPolicy
+	replacement   rune
+	missing       runeSet
+}
+
+// A runeSet is a set of runes that remembers the order they were added in.
+type runeSet struct {
+	list []rune
+	seen map[rune]bool
+}
+
+func (s *runeSet) add(r rune) {
+	if s.seen[r] {
+		return
+	}
+	if s.seen == nil {
+		s.seen = make(map[rune]bool)
+	}
+	s.seen[r] = true
+	s.list = append(s.list, r)
+}
+
+// SetHinting sets the hinting mode used when reading the font's metrics. The
+// default is font.HintingNone. With hinting, advance widths are rounded to
+// whole units (of 1/1000 em) by the font's own rules, which can differ from
+// simple rounding for some fonts. The same metrics are used for measuring
+// text and for the widths written into the PDF, so they always agree.
+func (f *Font) SetHinting(h font.Hinting) {
+	f.hinting = h
+}
+
+// advance returns the advance width of g, in 1/1000 em. All of the font's
+// widths come from here, so that the widths used for text layout match the
+// widths in the font dictionary.
+func (f *Font) advance(buffer *sfnt.Buffer, g sfnt.GlyphIndex) (int, error) {
+	a, err := f.sfnt.GlyphAdvance(buffer, g, fixed.I(1000), f.hinting)
+	return a.Round(), err
+}
+
+// kern returns the kerning adjustment between g0 and g1, in 1/1000 em.
+func (f *Font) kern(buffer *sfnt.Buffer, g0, g1 sfnt.GlyphIndex) (int, error) {
+	k, err := f.sfnt.Kern(buffer, g0, g1, fixed.I(1000), f.hinting)
+	return k.Round(), err
+}
+
+// ParseFont parses a TrueType or OpenType font. The Font can be used in any
+// number of documents (see Template).
+func ParseFont(data []byte) (*Font, error) {
+	f := &Font{
+		encode: make(map[rune]byte),
+	}
+	var err error
+	f.sfnt, err = sfnt.Parse(data)
+	if err != nil {
+		return nil, err
+	}
+	return f, nil
 }
 
 // LoadFont loads a TrueType or OpenType font from the file specified. If it
@@ -33,12 +111,7 @@ func (d *Document) LoadFont(filename string) (*Font, error) {
 	if err != nil {
 		return nil, err
 	}
-
-	f := &Font{
-		encode: make(map[rune]byte),
-	}
-
-	f.sfnt, err = sfnt.Parse(b)
+	f, err := ParseFont(b)
 	if err != nil {
 		return nil, err
 	}
@@ -66,6 +139,7 @@ func (f *Font) writeTo(e *encoder) {
 	}
 	widths := make([]int, lastChar-firstChar+1)
 	cp := &charProcs{procs: make(map[string]*type3Glyph)}
+	text := f.unicodeText()
 	var differences []string
 	prevDifference := -1
 
@@ -88,11 +162,15 @@ func (f *Font) writeTo(e *encoder) {
 		if err != nil {
 			continue
 		}
-		w, err := f.sfnt.GlyphAdvance(&buffer, g, fixed.I(1000), font.HintingNone)
+		w, err := f.advance(&buffer, g)
 		if err != nil {
 			continue
 		}
-		widths[i-firstChar] = w.Round()
+		widths[i-firstChar] = w
+		if f.outlines {
+			cp.procs[name] = &type3Glyph{width: w}
+			continue
+		}
 		outlines, err := f.sfnt.LoadGlyph(&buffer, g, fixed.I(1000), nil)
 		if err != nil {
 			log.Println(err)
@@ -100,14 +178,14 @@ func (f *Font) writeTo(e *encoder) {
 		}
 		cp.procs[name] = &type3Glyph{
 			outline: outlines,
-			width:   w.Round(),
+			width:   w,
 		}
 	}
 
 	var buffer sfnt.Buffer
 
 	var bounds []int
-	rawBounds, err := f.sfnt.Bounds(&buffer, fixed.I(1000), font.HintingNone)
+	rawBounds, err := f.sfnt.Bounds(&buffer, fixed.I(1000), f.hinting)
 	if err == nil {
 		bounds = []int{rawBounds.Min.X.Floor(), -rawBounds.Max.Y.Ceil(), rawBounds.Max.X.Ceil(), -rawBounds.Min.Y.Floor()}
 	}
@@ -123,9 +201,24 @@ func (f *Font) writeTo(e *encoder) {
 	fmt.Fprintf(e, "/FirstChar %d /LastChar %d\n", firstChar, lastChar)
 	fmt.Fprintf(e, "/Widths %d\n", widths)
 	fmt.Fprintf(e, "/CharProcs %d 0 R\n", e.getRef(cp))
+	if len(text) > 0 {
+		fmt.Fprintf(e, "/ToUnicode %d 0 R\n", e.getRef(toUnicodeCMap(text)))
+	}
 	fmt.Fprint(e, ">>")
 }
 
+// unicodeText returns the text represented by each character code in use,
+// for the ToUnicode CMap.
+func (f *Font) unicodeText() map[byte]string {
+	text := make(map[byte]string)
+	for i, r := range f.toUnicode {
+		if r != 0 {
+			text[byte(i)] = string(r)
+		}
+	}
+	return text
+}
+
 type type3Glyph struct {
 	outline []sfnt.Segment
 	width   int
@@ -160,7 +253,7 @@ func (g *type3Glyph) writeTo(e *encoder) {
 	}
 	min.Y, max.Y = -max.Y, -min.Y
 
-	s := new(stream)
+	s := &stream{content: true}
 	fmt.Fprintf(s, "%d 0 %d %d %d %d d1\n", g.width, min.X.Floor(), min.Y.Floor(), max.X.Ceil(), max.Y.Ceil())
 	var current fixed.Point26_6
 	for _, segment := range g.outline {
@@ -217,6 +310,9 @@ func (c *charProcs) writeTo(e *encoder) {
 }
 
 func (p *Page) SetFont(f *Font, size float64) {
+	if f == nil {
+		panic("pdf: SetFont called with a nil *Font")
+	}
 	fontID, ok := p.fonts[f]
 	if !ok {
 		if p.fonts == nil {
@@ -226,14 +322,46 @@ func (p *Page) SetFont(f *Font, size float64) {
 		p.fonts[f] = fontID
 	}
 
-	fmt.Fprintf(p.contents, "/F%d %g Tf ", fontID, size)
+	p.contents.name(fmt.Sprintf("F%d", fontID))
+	p.contents.num(size)
+	p.contents.op("Tf")
 	p.currentFont = f
 	p.currentSize = size
 }
 
+// SetDefaultFont sets a font and size that each page starts with, so that
+// text can be drawn without calling SetFont first. It is selected when
+// text is first drawn on a page (or in a header, footer, or Prepend
+// fragment) without a font having been set.
+func (d *Document) SetDefaultFont(f *Font, size float64) {
+	d.defaultFont, d.defaultSize = f, size
+}
+
+// font returns the current font, selecting the document's default font
+// first if no font has been set. If there is no default font either, it
+// panics with a message explaining the mistake, rather than letting the
+// caller dereference a nil *Font.
+func (p *Page) font() *Font {
+	p.selectDefaultFont()
+	if p.currentFont == nil {
+		panic("pdf: no font set; call Page.SetFont (or Document.SetDefaultFont) before drawing text")
+	}
+	return p.currentFont
+}
+
+// selectDefaultFont selects the document's default font, if it has one and
+// no font has been set yet.
+func (p *Page) selectDefaultFont() {
+	if p.currentFont == nil && p.doc != nil && p.doc.defaultFont != nil {
+		p.SetFont(p.doc.defaultFont, p.doc.defaultSize)
+	}
+}
+
 // SetLeading sets the line spacing to be used by Multiline.
 func (p *Page) SetLeading(leading float64) {
-	fmt.Fprintf(p.contents, "%g TL ", leading)
+	p.contents.num(leading)
+	p.contents.op("TL")
+	p.leading = leading
 }
 
 func (f *Font) encodeRune(r rune) (b byte, ok bool) {
@@ -274,25 +402,85 @@ func (f *Font) encodeRune(r rune) (b byte, ok bool) {
 func (f *Font) encodeString(s string) string {
 	b := make([]byte, 0, len(s))
 	for _, r := range s {
+		if f.missingPolicy != MissingGlyphNotdef && !f.hasGlyph(r) {
+			switch f.missingPolicy {
+			case MissingGlyphReplace:
+				r = f.replacement
+			case MissingGlyphDrop:
+				continue
+			case MissingGlyphError:
+				f.missing.add(r)
+				continue
+			}
+		}
 		c, ok := f.encodeRune(r)
 		if ok {
 			b = append(b, c)
+		} else {
+			f.dropped.add(r)
 		}
 	}
 	return string(b)
 }
 
 func (f *Font) runeWidth(r rune) int {
+	if f.fixedAdvance != 0 {
+		return f.fixedAdvance
+	}
 	var buffer sfnt.Buffer
 	g, err := f.sfnt.GlyphIndex(&buffer, r)
 	if err != nil {
 		return 0
 	}
-	advance, err := f.sfnt.GlyphAdvance(&buffer, g, fixed.I(1000), font.HintingNone)
+	advance, err := f.advance(&buffer, g)
 	if err != nil {
 		return 0
 	}
-	return advance.Round()
+	return advance
+}
+
+// metrics returns the font's ascent and descent, in units of 1/1000 of an em.
+// The descent is positive for a descender that goes below the baseline.
+func (f *Font) metrics() (ascent, descent int) {
+	var buffer sfnt.Buffer
+	m, err := f.sfnt.Metrics(&buffer, fixed.I(1000), f.hinting)
+	if err != nil {
+		return 800, 200
+	}
+	return m.Ascent.Round(), m.Descent.Round()
+}
+
+// StringBounds returns how far the ink of the glyphs in s extends above
+// (ascent) and below (descent) the baseline at the given font size, using
+// each glyph's bounding box. The descent is positive for glyphs that go
+// below the baseline, and negative if the ink is all above it (as for a
+// hyphen). Unlike the font-wide ascent and descent, this gives a
+// tight box for the particular string: for example, a string with no
+// descenders has a descent of 0. A string with no visible glyphs returns
+// zeros.
+func (f *Font) StringBounds(s string, size float64) (ascent, descent float64) {
+	var buffer sfnt.Buffer
+	found := false
+	for _, r := range s {
+		g, err := f.sfnt.GlyphIndex(&buffer, r)
+		if err != nil {
+			continue
+		}
+		b, _, err := f.sfnt.GlyphBounds(&buffer, g, fixed.I(1000), f.hinting)
+		if err != nil || b.Empty() {
+			continue
+		}
+		// In sfnt's coordinates, y increases downward.
+		a, d := -float64(b.Min.Y)/64, float64(b.Max.Y)/64
+		if !found || a > ascent {
+			ascent = a
+		}
+		if !found || d > descent {
+			descent = d
+		}
+		found = true
+	}
+	return ascent * size / 1000, descent * size / 1000
 }
 
 // encodeAndKern converts s from UTF-8 to a format suitable for displaying with
@@ -300,7 +488,15 @@ func (f *Font) runeWidth(r rune) int {
 // in units of 1/1000 of an em. If maxWidth is nonzero and s is too long to fit,
 // the result will be truncated.
 func (f *Font) encodeAndKern(s string, maxWidth int) (tj []string, width int) {
-	s = f.encodeString(s)
+	return f.kernEncoded(f.encodeString(s), maxWidth)
+}
+
+// kernEncoded is like encodeAndKern, but s has already been converted to
+// character codes with encodeString.
+func (f *Font) kernEncoded(s string, maxWidth int) (tj []string, width int) {
+	if f.fixedAdvance != 0 {
+		return f.encodeFixed(s, maxWidth)
+	}
 	var buffer sfnt.Buffer
 
 	var prevGlyph sfnt.GlyphIndex
@@ -310,22 +506,22 @@ func (f *Font) encodeAndKern(s string, maxWidth int) (tj []string, width int) {
 		if err != nil {
 			continue
 		}
-		advance, err := f.sfnt.GlyphAdvance(&buffer, g, fixed.I(1000), font.HintingNone)
+		advance, err := f.advance(&buffer, g)
 		if err == nil {
 			oldWidth := width
-			width += advance.Round()
+			width += advance
 			if maxWidth != 0 && width > maxWidth {
 				tj = append(tj, quoteString(s[chunkStart:i]))
 				return tj, oldWidth
 			}
 		}
 		if i != 0 {
-			kern, err := f.sfnt.Kern(&buffer, prevGlyph, g, fixed.I(1000), font.HintingNone)
+			kern, err := f.kern(&buffer, prevGlyph, g)
 			if err == nil && kern != 0 {
-				width += kern.Round()
+				width += kern
 				tj = append(tj,
 					quoteString(s[chunkStart:i]),
-					strconv.Itoa(-kern.Round()),
+					strconv.Itoa(-kern),
 				)
 				chunkStart = i
 			}
@@ -337,6 +533,154 @@ func (f *Font) encodeAndKern(s string, maxWidth int) (tj []string, width int) {
 	return tj, width
 }
 
+// Preload assigns character codes to the characters in runes before they
+// are used, so that a document's character coverage can be checked before
+// anything is drawn. It returns an error listing the characters that the font
+// has no glyphs for, or that could not be encoded because the font already
+// uses all 255 character codes.
+func (f *Font) Preload(runes string) error {
+	var missing, dropped []rune
+	for _, r := range runes {
+		if !f.hasGlyph(r) {
+			missing = append(missing, r)
+			continue
+		}
+		if _, ok := f.encodeRune(r); !ok {
+			dropped = append(dropped, r)
+		}
+	}
+
+	switch {
+	case len(missing) > 0 && len(dropped) > 0:
+		return fmt.Errorf("no glyphs for %q; too many different characters to encode %q", string(missing), string(dropped))
+	case len(missing) > 0:
+		return fmt.Errorf("no glyphs for %q", string(missing))
+	case len(dropped) > 0:
+		return fmt.Errorf("too many different characters to encode %q", string(dropped))
+	}
+	return nil
+}
+
+// A MissingGlyphPolicy specifies what is done with characters that a font
+// has no glyphs for.
+type MissingGlyphPolicy int
+
+const (
+	// MissingGlyphNotdef displays the font's .notdef glyph, which is
+	// usually a box or a blank space. This is the default.
+	MissingGlyphNotdef MissingGlyphPolicy = iota
+
+	// MissingGlyphReplace displays a replacement character instead (see
+	// SetMissingGlyphReplacement).
+	MissingGlyphReplace
+
+	// MissingGlyphDrop leaves the characters out.
+	MissingGlyphDrop
+
+	// MissingGlyphError leaves the characters out, and Validate reports
+	// them as errors.
+	MissingGlyphError
+)
+
+// SetMissingGlyphPolicy sets what is done with characters that f has no
+// glyphs for. It affects text measurement as well as drawing, so it should
+// be set before f is used.
+func (f *Font) SetMissingGlyphPolicy(policy MissingGlyphPolicy) {
+	f.missingPolicy = policy
+	if f.replacement == 0 {
+		f.replacement = '?'
+		if f.hasGlyph('\uFFFD') {
+			f.replacement = '\uFFFD'
+		}
+	}
+}
+
+// SetMissingGlyphReplacement sets the character displayed in place of
+// missing characters under MissingGlyphReplace. The default is U+FFFD
+// (the Unicode replacement character) if the font has it, and "?" if it
+// doesn't.
+func (f *Font) SetMissingGlyphReplacement(r rune) {
+	f.replacement = r
+}
+
+// SetFixedAdvance makes f a monospaced font, with every character taking up
+// w units (in 1/1000 of an em), and no kerning. Each glyph is centered in its
+// space, so that columns of figures and code listings line up even if the
+// font's glyphs are proportionally spaced. If w is 0, the font's natural
+// advance widths are used.
+func (f *Font) SetFixedAdvance(w int) {
+	f.fixedAdvance = w
+}
+
+// widthAfter returns the width (in 1/1000 em) that the encoded string s adds
+// to a line when it follows prev, including the kerning between the last
+// character of prev and the first character of s, as encodeAndKern would
+// measure it.
+func (f *Font) widthAfter(buffer *sfnt.Buffer, prev, s string) int {
+	if f.fixedAdvance != 0 {
+		return f.fixedAdvance * len(s)
+	}
+	width := 0
+	var prevGlyph sfnt.GlyphIndex
+	havePrev := false
+	if prev != "" {
+		if g, err := f.sfnt.GlyphIndex(buffer, f.toUnicode[prev[len(prev)-1]]); err == nil {
+			prevGlyph, havePrev = g, true
+		}
+	}
+	for i := 0; i < len(s); i++ {
+		g, err := f.sfnt.GlyphIndex(buffer, f.toUnicode[s[i]])
+		if err != nil {
+			continue
+		}
+		if advance, err := f.advance(buffer, g); err == nil {
+			width += advance
+		}
+		if havePrev {
+			if kern, err := f.kern(buffer, prevGlyph, g); err == nil {
+				width += kern
+			}
+		}
+		prevGlyph, havePrev = g, true
+	}
+	return width
+}
+
+// encodeFixed is the version of encodeAndKern for monospaced mode. The string
+// s has already been encoded.
+func (f *Font) encodeFixed(s string, maxWidth int) (tj []string, width int) {
+	var buffer sfnt.Buffer
+	chunkStart := 0
+	adjustment := 0
+	for i := 0; i < len(s); i++ {
+		if maxWidth != 0 && width+f.fixedAdvance > maxWidth {
+			s = s[:i]
+			break
+		}
+		width += f.fixedAdvance
+
+		natural := f.fixedAdvance
+		if g, err := f.sfnt.GlyphIndex(&buffer, f.toUnicode[s[i]]); err == nil {
+			if advance, err := f.advance(&buffer, g); err == nil {
+				natural = advance
+			}
+		}
+		extra := f.fixedAdvance - natural
+		adjustment -= extra / 2
+		if adjustment != 0 {
+			tj = append(tj, quoteString(s[chunkStart:i]), strconv.Itoa(adjustment))
+			chunkStart = i
+		}
+		adjustment = -(extra - extra/2)
+	}
+	tj = append(tj, quoteString(s[chunkStart:]))
+	if adjustment != 0 {
+		tj = append(tj, strconv.Itoa(adjustment))
+	}
+
+	return tj, width
+}
+
 var stringEscaper = strings.NewReplacer("\n", `\n`, "\r", `\r`, "\t", `\t`, "(", `\(`, ")", `\)`, `\`, `\\`)
 
 func quoteString(s string) string {
@@ -346,51 +690,213 @@ func quoteString(s string) string {
 // beginText begins a text object. All text output and positioning must happen
 // between calls to BeginText and EndText.
 func (p *Page) beginText() {
-	fmt.Fprint(p.contents, "BT ")
+	// Select the default font (if necessary) before the graphics state is
+	// saved for the text color, so that it stays in effect afterward. If
+	// there is no default font, the caller may still call SetFont inside
+	// the text object.
+	p.selectDefaultFont()
+	if p.textColor != nil {
+		p.contents.op("q")
+		p.textColor.setColor(p, false)
+		if p.textOverprint {
+			p.setExtGState("/op true /OPM 1")
+		}
+		p.textSaved = true
+	}
+	p.contents.op("BT")
+	p.tm, p.tlm = identityMatrix, identityMatrix
+	if p.topDown {
+		// Flip text space back, so that the text is right side up.
+		p.TextMatrix(1, 0, 0, 1, 0, 0)
+	}
 }
 
 func (p *Page) endText() {
-	fmt.Fprint(p.contents, "ET ")
+	p.contents.op("ET")
+	p.flushOutlines()
+	if p.textSaved {
+		p.contents.op("Q")
+		p.textSaved = false
+	}
+}
+
+// SetTextColor sets the color used to fill text drawn by the text methods,
+// independently of the fill color used by Fill. The fill color is changed
+// only for the duration of each text object, so drawing text doesn't affect
+// the color of shapes drawn afterward. If c is nil (the default), text is
+// drawn in the current fill color.
+//
+// Because the graphics state is saved and restored around each text object,
+// SetFont and SetFillColor calls made between BeginText and EndText don't
+// last beyond EndText when a text color is set.
+func (p *Page) SetTextColor(c Color) {
+	p.textColor = c
+	p.textOverprint = false
 }
 
 // show puts s on the page.
 func (p *Page) show(s string) {
-	tj, _ := p.currentFont.encodeAndKern(s, 0)
-	fmt.Fprintf(p.contents, "%v TJ ", tj)
+	tj, _ := p.font().encodeAndKern(s, 0)
+	p.showTJ(tj)
+}
+
+// BeginText begins a text object. The text positioning and showing methods
+// (TextMatrix, TextMove, TextNextLine, and ShowText) must be called between
+// BeginText and EndText. The other text methods, such as Left, begin and end
+// their own text objects, so they must not be called inside one.
+func (p *Page) BeginText() {
+	p.beginText()
+}
+
+// EndText ends a text object.
+func (p *Page) EndText() {
+	p.endText()
+}
+
+// TextMatrix sets the text matrix (and the text line matrix) to
+// [a b c d e f], which places the start of the current line at (e, f), and
+// can also rotate, scale, or skew the text.
+func (p *Page) TextMatrix(a, b, c, d, e, f float64) {
+	if p.topDown {
+		c, d = -c, -d
+	}
+	p.contents.num(a, b, c, d, e, f)
+	p.contents.op("Tm")
+	p.tlm = [6]float64{a, b, c, d, e, f}
+	p.tm = p.tlm
+}
+
+// TextMove moves to the start of the next line, offset from the start of the
+// current line by (dx, dy), in text space.
+func (p *Page) TextMove(dx, dy float64) {
+	if p.topDown {
+		dy = -dy
+	}
+	p.contents.num(dx, dy)
+	p.contents.op("Td")
+	p.moveTextLine(dx, dy)
+}
+
+// TextNextLine moves to the start of the next line, using the leading set
+// with SetLeading.
+func (p *Page) TextNextLine() {
+	p.contents.op("T*")
+	p.moveTextLine(0, -p.leading)
+}
+
+// ShowText puts s on the page at the current text position, which is
+// advanced to the end of s.
+func (p *Page) ShowText(s string) {
+	p.show(s)
+}
+
+// measure encodes s in the current font, and returns its width in points,
+// including the character spacing set with SetCharSpacing.
+func (p *Page) measure(s string) (tj []string, width float64) {
+	encoded := p.font().encodeString(s)
+	tj, w := p.font().kernEncoded(encoded, 0)
+	width = float64(w)*0.001*p.currentSize + p.charSpacing*float64(len(encoded))
+	return tj, width
+}
+
+// showTJ shows text that has already been encoded for the TJ operator.
+func (p *Page) showTJ(tj []string) {
+	if !p.font().outlines {
+		p.contents.array(tj)
+		p.contents.op("TJ")
+		p.advanceText(tj, nil)
+		return
+	}
+
+	p.addOutlines(tj)
+	if p.clipText {
+		p.contents.array(tj)
+		p.contents.op("TJ")
+		return
+	}
+	p.contents.num(3)
+	p.contents.op("Tr")
+	p.contents.array(tj)
+	p.contents.op("TJ")
+	p.contents.num(0)
+	p.contents.op("Tr")
+}
+
+// TextPosition returns the current text position: the point where the next
+// text shown by ShowText will start. SetFont may be called between runs of
+// text in the same text object, so text in different fonts and sizes can
+// share a baseline:
+//
+//	p.BeginText()
+//	p.SetFont(f, 24)
+//	p.TextMove(x, y)
+//	p.ShowText("Product")
+//	p.SetFont(f, 10)
+//	p.ShowText("™")
+//	p.EndText()
+func (p *Page) TextPosition() (x, y float64) {
+	return p.tm[4], p.tm[5]
+}
+
+// textAt moves to (x, y), relative to the start of the current line, and
+// shows tj there.
+func (p *Page) textAt(x, y float64, tj []string) {
+	p.TextMove(x, y)
+	p.showTJ(tj)
 }
 
 // Left puts s on the page, left-aligned at (x, y).
 func (p *Page) Left(x, y float64, s string) {
 	p.beginText()
-	fmt.Fprintf(p.contents, "%g %g Td ", x, y)
+	p.TextMove(x, y)
 	p.show(s)
 	p.endText()
 }
 
+// TextBlock puts s on the page, left-aligned at (x, y), like Left, and
+// returns the rectangle it occupies. The rectangle extends from the font's
+// descent below the baseline to its ascent above it, so it is the same height
+// for any text in the same font and size.
+func (p *Page) TextBlock(x, y float64, s string) Rect {
+	p.beginText()
+	tj, w := p.measure(s)
+	p.textAt(x, y, tj)
+	p.endText()
+
+	ascent, descent := p.font().metrics()
+	return Rect{
+		X0: x,
+		Y0: y - float64(descent)*0.001*p.currentSize,
+		X1: x + w,
+		Y1: y + float64(ascent)*0.001*p.currentSize,
+	}
+}
+
 // Right puts s on the page, right-aligned at (x, y).
 func (p *Page) Right(x, y float64, s string) {
 	p.beginText()
-	tj, w := p.currentFont.encodeAndKern(s, 0)
-	fmt.Fprintf(p.contents, "%g %g Td %v TJ ", x-float64(w)*0.001*p.currentSize, y, tj)
+	tj, w := p.measure(s)
+	p.textAt(x-w, y, tj)
 	p.endText()
 }
 
 // Center puts s on the page, centered at (x, y).
 func (p *Page) Center(x, y float64, s string) {
 	p.beginText()
-	tj, w := p.currentFont.encodeAndKern(s, 0)
-	fmt.Fprintf(p.contents, "%g %g Td %v TJ ", x-float64(w)*0.001*p.currentSize*0.5, y, tj)
+	tj, w := p.measure(s)
+	p.textAt(x-w*0.5, y, tj)
 	p.endText()
 }
 
 // Multiline puts multiple lines of text on the page (splitting s at '\n'). It
-// uses the line spacing set with Leading.
+// uses the line spacing set with Leading. To wrap long lines as well, use
+// TextArea.
 func (p *Page) Multiline(x, y float64, s string) {
 	p.beginText()
-	fmt.Fprintf(p.contents, "%g %g Td ", x, y)
+	p.TextMove(x, y)
 	for i, line := range strings.Split(s, "\n") {
 		if i > 0 {
-			fmt.Fprint(p.contents, "T* ")
+			p.TextNextLine()
 		}
 		p.show(line)
 	}
@@ -398,49 +904,478 @@ func (p *Page) Multiline(x, y float64, s string) {
 }
 
 // Truncate displays s at (x, y), truncating it with an ellipsis if it is
-// longer than width.
+// longer than width. The text is only cut between grapheme clusters, so that
+// a base character is never separated from the combining marks or other
+// modifiers that follow it. If the font has no "…" glyph, "..." is used
+// instead. The width includes the character spacing (see SetCharSpacing);
+// if not even the ellipsis fits, nothing is drawn.
 func (p *Page) Truncate(x, y, width float64, s string) {
-	scaledWidth := int(width / p.currentSize * 1000)
-	p.beginText()
-	fmt.Fprintf(p.contents, "%g %g Td ", x, y)
-	if full, w := p.currentFont.encodeAndKern(s, 0); w <= scaledWidth {
-		fmt.Fprintf(p.contents, "%v TJ ", full)
+	font := p.font()
+	// The widths are in 1/1000 em, and include the character spacing,
+	// which is added after each character code.
+	limit := (width + fitTolerance) / p.currentSize * 1000
+	spacing := p.charSpacing / p.currentSize * 1000
+	encoded := font.encodeString(s)
+	if full, w := font.kernEncoded(encoded, 0); float64(w)+spacing*float64(len(encoded)) <= limit {
+		p.beginText()
+		p.TextMove(x, y)
+		p.showTJ(full)
 		p.endText()
 		return
 	}
-	tj, _ := p.currentFont.encodeAndKern(s, scaledWidth-p.currentFont.runeWidth('…'))
-	ellipsis, ok := p.currentFont.encodeRune('…')
-	if ok {
-		tj = append(tj, quoteString(string([]byte{ellipsis})))
+
+	ellipsis := "…"
+	if !font.hasGlyph('…') {
+		ellipsis = "..."
+	}
+	ellipsis = font.encodeString(ellipsis)
+	var buffer sfnt.Buffer
+	ellipsisWidth := float64(font.widthAfter(&buffer, "", ellipsis)) + spacing*float64(len(ellipsis))
+	if ellipsisWidth > limit {
+		return
+	}
+
+	var prefix []byte
+	prefixWidth := 0.0
+	last := ""
+	for _, cluster := range graphemeClusters(s) {
+		c := font.encodeString(cluster)
+		w := prefixWidth + float64(font.widthAfter(&buffer, last, c)) + spacing*float64(len(c))
+		next := last
+		if c != "" {
+			next = c
+		}
+		if w+float64(font.widthAfter(&buffer, next, ellipsis))+spacing*float64(len(ellipsis)) > limit {
+			break
+		}
+		prefix = append(prefix, c...)
+		prefixWidth = w
+		last = next
+	}
+
+	p.beginText()
+	p.TextMove(x, y)
+	tj, _ := font.kernEncoded(string(prefix)+ellipsis, 0)
+	p.showTJ(tj)
+	p.endText()
+}
+
+// FitOrTruncate displays s at (x, y) on one line no wider than width. If s
+// is too wide at the current font size, the size is reduced (but not below
+// minSize) to make it fit; if it still doesn't fit at minSize, it is
+// truncated with an ellipsis, as with Truncate. The text is never enlarged,
+// even if minSize is larger than the current size. The font size is
+// restored afterward.
+func (p *Page) FitOrTruncate(x, y, width, minSize float64, s string) {
+	size := p.currentSize
+	if minSize > size {
+		minSize = size
+	}
+	_, w := p.measure(s)
+	if w <= width+fitTolerance {
+		p.Left(x, y, s)
+		return
+	}
+
+	// The character spacing doesn't change with the font size, so only
+	// the glyphs are scaled.
+	_, glyphs := p.font().encodeAndKern(s, 0)
+	glyphWidth := float64(glyphs) * 0.001 * size
+	fitSize := size * (width - (w - glyphWidth)) / glyphWidth
+	font := p.currentFont
+	if fitSize >= minSize {
+		p.SetFont(font, fitSize)
+		p.Left(x, y, s)
+	} else {
+		p.SetFont(font, minSize)
+		p.Truncate(x, y, width, s)
+	}
+	p.SetFont(font, size)
+}
+
+// fitTolerance is the amount (in points) by which text may exceed the width
+// it is fitted to, to allow for rounding errors.
+const fitTolerance = 0.001
+
+// TextOnArc displays s along a circle centered at (cx, cy) with radius r,
+// with the baseline on the circle and the tops of the characters facing
+// outward, as on a seal or badge. The text starts at the angle startDeg
+// (in degrees, counterclockwise from the positive x axis) and runs
+// clockwise. Each grapheme cluster is placed and rotated individually,
+// using its advance width in the current font.
+func (p *Page) TextOnArc(cx, cy, r, startDeg float64, s string) {
+	if r <= 0 {
+		return
+	}
+	a := startDeg * math.Pi / 180
+	p.beginText()
+	for _, cluster := range graphemeClusters(s) {
+		tj, w := p.measure(cluster)
+		// The glyph's baseline is along the clockwise tangent, and its
+		// vertical axis points away from the center.
+		sin, cos := math.Sin(a), math.Cos(a)
+		p.TextMatrix(sin, -cos, cos, sin, cx+r*cos, cy+r*sin)
+		p.showTJ(tj)
+		a -= w / r
 	}
-	fmt.Fprintf(p.contents, "%v TJ ", tj)
 	p.endText()
 }
 
+// A PathOp is the kind of a PathSegment.
+type PathOp int
+
+// The kinds of path segments.
+const (
+	PathMoveTo PathOp = iota
+	PathLineTo
+	PathCurveTo
+	PathClose
+)
+
+// A PathSegment is one segment of a path. PathMoveTo and PathLineTo use
+// the first point in Points; PathCurveTo uses all three (two control points
+// and the end point), and PathClose uses none.
+type PathSegment struct {
+	Op     PathOp
+	Points [3][2]float64
+}
+
+// GlyphPath returns the outline of the glyph for r, scaled to the given
+// font size, with its origin at the start of the glyph's baseline and the y
+// axis pointing up. Quadratic curves (in TrueType fonts) are converted to
+// cubic curves.
+func (f *Font) GlyphPath(r rune, size float64) ([]PathSegment, error) {
+	var buffer sfnt.Buffer
+	g, err := f.sfnt.GlyphIndex(&buffer, r)
+	if err != nil {
+		return nil, err
+	}
+	outline, err := f.sfnt.LoadGlyph(&buffer, g, fixed.I(1000), nil)
+	if err != nil {
+		return nil, err
+	}
+
+	scale := size / 1000
+	point := func(p fixed.Point26_6) [2]float64 {
+		return [2]float64{float64(p.X) / 64 * scale, -float64(p.Y) / 64 * scale}
+	}
+	var path []PathSegment
+	var current [2]float64
+	for i, segment := range outline {
+		switch segment.Op {
+		case sfnt.SegmentOpMoveTo:
+			if i > 0 {
+				path = append(path, PathSegment{Op: PathClose})
+			}
+			current = point(segment.Args[0])
+			path = append(path, PathSegment{Op: PathMoveTo, Points: [3][2]float64{current}})
+		case sfnt.SegmentOpLineTo:
+			current = point(segment.Args[0])
+			path = append(path, PathSegment{Op: PathLineTo, Points: [3][2]float64{current}})
+		case sfnt.SegmentOpQuadTo:
+			q, end := point(segment.Args[0]), point(segment.Args[1])
+			path = append(path, PathSegment{Op: PathCurveTo, Points: [3][2]float64{
+				{current[0] + (q[0]-current[0])*2/3, current[1] + (q[1]-current[1])*2/3},
+				{end[0] + (q[0]-end[0])*2/3, end[1] + (q[1]-end[1])*2/3},
+				end,
+			}})
+			current = end
+		case sfnt.SegmentOpCubeTo:
+			current = point(segment.Args[2])
+			path = append(path, PathSegment{Op: PathCurveTo, Points: [3][2]float64{
+				point(segment.Args[0]), point(segment.Args[1]), current,
+			}})
+		}
+	}
+	if len(path) > 0 {
+		path = append(path, PathSegment{Op: PathClose})
+	}
+	return path, nil
+}
+
+// AddPath adds the segments in path to the current path, offset by
+// (x, y).
+func (p *Page) AddPath(x, y float64, path []PathSegment) {
+	for _, s := range path {
+		switch s.Op {
+		case PathMoveTo:
+			p.MoveTo(x+s.Points[0][0], y+s.Points[0][1])
+		case PathLineTo:
+			p.LineTo(x+s.Points[0][0], y+s.Points[0][1])
+		case PathCurveTo:
+			p.CurveTo(x+s.Points[0][0], y+s.Points[0][1], x+s.Points[1][0], y+s.Points[1][1], x+s.Points[2][0], y+s.Points[2][1])
+		case PathClose:
+			p.ClosePath()
+		}
+	}
+}
+
+// DrawGlyphOutline adds the outline of the glyph for r, in the current font
+// and size, to the current path, with the glyph's origin at (x, y). The
+// path can then be filled, stroked, or used for clipping like any other.
+func (p *Page) DrawGlyphOutline(x, y float64, r rune) {
+	path, err := p.font().GlyphPath(r, p.currentSize)
+	if err != nil {
+		p.problems = append(p.problems, fmt.Sprintf("no outline for %q: %v", r, err))
+		return
+	}
+	p.AddPath(x, y, path)
+}
+
+// hasGlyph reports whether f has a glyph for r.
+func (f *Font) hasGlyph(r rune) bool {
+	var buffer sfnt.Buffer
+	g, err := f.sfnt.GlyphIndex(&buffer, r)
+	return err == nil && g != 0
+}
+
+// graphemeClusters splits s into approximate grapheme clusters: each base
+// character along with any combining marks, variation selectors, and emoji
+// modifiers that follow it, and any characters joined to it with a zero
+// width joiner.
+func graphemeClusters(s string) []string {
+	var clusters []string
+	start := 0
+	joined := false
+	for i, r := range s {
+		if i > start && !joined && !extendsCluster(r) {
+			clusters = append(clusters, s[start:i])
+			start = i
+		}
+		joined = r == '\u200d'
+	}
+	if start < len(s) {
+		clusters = append(clusters, s[start:])
+	}
+	return clusters
+}
+
+// extendsCluster reports whether r continues the grapheme cluster of the
+// character before it.
+func extendsCluster(r rune) bool {
+	switch {
+	case unicode.In(r, unicode.Mn, unicode.Me, unicode.Mc):
+		return true
+	case r == '\u200d': // zero width joiner
+		return true
+	case r >= 0xfe00 && r <= 0xfe0f, r >= 0xe0100 && r <= 0xe01ef: // variation selectors
+		return true
+	case r >= 0x1f3fb && r <= 0x1f3ff: // emoji skin tone modifiers
+		return true
+	}
+	return false
+}
+
 // WordWrap displays s on multiple lines, wrapping at word boundaries to keep
 // the width less than margin.
 func (p *Page) WordWrap(x, y, margin float64, s string) {
 	scaledMargin := int(margin / p.currentSize * 1000)
+	p.beginTag("P")
 	p.beginText()
-	fmt.Fprintf(p.contents, "%g %g Td ", x, y)
-	words := strings.Fields(s)
-	i := 0
-	for i < len(words) {
-		line, lineWidth := p.currentFont.encodeAndKern(words[i], 0)
-		i++
-		for i < len(words) {
-			word, wordWidth := p.currentFont.encodeAndKern(" "+words[i], 0)
-			if lineWidth+wordWidth > scaledMargin {
-				break
+	p.TextMove(x, y)
+	for i, line := range p.font().wrapLines(s, scaledMargin) {
+		if i > 0 {
+			p.TextNextLine()
+		}
+		p.showTJ(line.tj)
+	}
+	p.endText()
+	p.endTag()
+}
+
+// TextArea displays s on multiple lines, starting with the baseline at y.
+// Like Multiline, it starts a new line at each '\n', and like WordWrap, it
+// wraps lines that are wider than width. An empty line (as between two
+// consecutive newlines) leaves a blank line. It returns the baseline of the
+// last line.
+func (p *Page) TextArea(x, y, width float64, s string) (bottomY float64) {
+	scaledWidth := int(width / p.currentSize * 1000)
+	p.beginTag("P")
+	p.beginText()
+	p.TextMove(x, y)
+	lines := 0
+	for _, segment := range strings.Split(s, "\n") {
+		wrapped := p.font().wrapLines(segment, scaledWidth)
+		if len(wrapped) == 0 {
+			wrapped = []wrappedLine{{}}
+		}
+		for _, line := range wrapped {
+			if lines > 0 {
+				p.TextNextLine()
 			}
-			line = append(line, word...)
-			lineWidth += wordWidth
-			i++
+			if len(line.tj) > 0 {
+				p.showTJ(line.tj)
+			}
+			lines++
+		}
+	}
+	p.endText()
+	p.endTag()
+	return y - float64(lines-1)*p.leading
+}
+
+// A wrappedLine is one line of text produced by wrapLines.
+type wrappedLine struct {
+	words []string
+	tj    []string
+	width int
+}
+
+// wrapLines splits s into lines, wrapping at word boundaries to keep the
+// width of each line (in 1/1000 em) no more than maxWidth.
+func (f *Font) wrapLines(s string, maxWidth int) []wrappedLine {
+	return f.hyphenatedLines(s, maxWidth, nil)
+}
+
+// A WrappedLine is one line of text produced by WrapLines.
+type WrappedLine struct {
+	Text string
+
+	// Width is the width of the line, in points.
+	Width float64
+}
+
+// WrapLines splits s into lines no wider than width at the given font size,
+// breaking them the same way WordWrap does, and returns the lines and their
+// widths instead of drawing them.
+func (f *Font) WrapLines(s string, width, size float64) []WrappedLine {
+	var lines []WrappedLine
+	for _, line := range f.wrapLines(s, int(width/size*1000)) {
+		lines = append(lines, WrappedLine{
+			Text:  strings.Join(line.words, " "),
+			Width: float64(line.width) * 0.001 * size,
+		})
+	}
+	return lines
+}
+
+// ClipText sets the clipping path to the outlines of s, left-aligned at
+// (x, y). Subsequent drawing is only visible inside the glyphs, so filling a
+// rectangle that covers the text fills the text with that color, image, or
+// gradient. Since the clipping path can only be reset by restoring the
+// graphics state, ClipText should be called between Save and Restore.
+func (p *Page) ClipText(x, y float64, s string) {
+	p.beginText()
+	p.contents.num(7)
+	p.contents.op("Tr")
+	p.TextMove(x, y)
+	p.clipText = true
+	p.show(s)
+	p.endText()
+	p.clipText = false
+	p.contents.num(0)
+	p.contents.op("Tr")
+}
+
+// BeginLanguage marks the start of a span of content in a language different
+// from the document's language (set with Document.SetLanguage). Each call to
+// BeginLanguage must be matched by a call to EndLanguage.
+func (p *Page) BeginLanguage(lang string) {
+	p.contents.name("Span")
+	p.contents.dict("/Lang " + quoteString(lang))
+	p.contents.op("BDC")
+	p.markDepth++
+}
+
+// EndLanguage marks the end of a span started with BeginLanguage.
+func (p *Page) EndLanguage() {
+	p.contents.op("EMC")
+	p.markDepth--
+	if p.markDepth < 0 {
+		p.unbalanced = true
+	}
+}
+
+// A TextRun is a piece of text for ShowKerned, followed by a manual spacing
+// adjustment.
+type TextRun struct {
+	Text string
+
+	// Adjust is extra space to add after Text, in units of 1/1000 of an
+	// em. Negative values move the following text closer.
+	Adjust int
+}
+
+// ShowKerned puts the runs of text on the page, left-aligned at (x, y). The
+// text of each run is kerned automatically, and each run's Adjust value is
+// added to the spacing after it. It returns the total width of the text,
+// including the adjustments.
+func (p *Page) ShowKerned(x, y float64, runs []TextRun) (width float64) {
+	var tj []string
+	total := 0
+	for _, run := range runs {
+		chunk, w := p.font().encodeAndKern(run.Text, 0)
+		tj = append(tj, chunk...)
+		total += w
+		if run.Adjust != 0 {
+			tj = append(tj, strconv.Itoa(-run.Adjust))
+			total += run.Adjust
+		}
+	}
+
+	p.beginText()
+	p.textAt(x, y, tj)
+	p.endText()
+	return float64(total) * 0.001 * p.currentSize
+}
+
+// justify returns a TJ array for words, separated by spaces, with the spaces
+// widened to make the total width (in 1/1000 em) equal to width. The extra
+// space is added as adjustments in the TJ array, so it works regardless of
+// which character code the space character is assigned.
+func (f *Font) justify(words []string, width int) []string {
+	tj, natural := f.encodeAndKern(words[0], 0)
+	var rest [][]string
+	for _, word := range words[1:] {
+		chunk, w := f.encodeAndKern(" "+word, 0)
+		rest = append(rest, chunk)
+		natural += w
+	}
+
+	extra := width - natural
+	added := 0
+	for i, chunk := range rest {
+		// Distribute the rounding error so that the total is exact.
+		gap := extra*(i+1)/len(rest) - added
+		added += gap
+		if gap != 0 {
+			tj = append(tj, strconv.Itoa(-gap))
+		}
+		tj = append(tj, chunk...)
+	}
+	return tj
+}
+
+// Justify puts s on the page as a single line starting at (x, y), with the
+// spaces between words stretched (or shrunk) to make it exactly width wide.
+func (p *Page) Justify(x, y, width float64, s string) {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return
+	}
+	p.beginText()
+	p.textAt(x, y, p.font().justify(words, int(width/p.currentSize*1000)))
+	p.endText()
+}
+
+// WordWrapJustified displays s on multiple lines like WordWrap, but with all
+// lines except the last justified to a width of margin.
+func (p *Page) WordWrapJustified(x, y, margin float64, s string) {
+	scaledMargin := int(margin / p.currentSize * 1000)
+	p.beginTag("P")
+	p.beginText()
+	p.TextMove(x, y)
+	lines := p.font().wrapLines(s, scaledMargin)
+	for i, line := range lines {
+		if i > 0 {
+			p.TextNextLine()
 		}
-		fmt.Fprintf(p.contents, "%v TJ ", line)
-		if i < len(words) {
-			fmt.Fprint(p.contents, "T* ")
+		if i == len(lines)-1 {
+			p.showTJ(line.tj)
+		} else {
+			p.showTJ(p.font().justify(line.words, scaledMargin))
 		}
 	}
 	p.endText()
+	p.endTag()
 }
@@ -0,0 +1,376 @@
+package pdf
+
+import (
+	"bytes"
+	"regexp"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/gofont/goregular"
+	"golang.org/x/image/font/sfnt"
+)
+
+func testFont(t *testing.T) *Font {
+	t.Helper()
+	f, err := ParseFont(goregular.TTF)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return f
+}
+
+// checkFontInEffect checks that a font has been selected with Tf, and not
+// undone by Q, at each BT in content.
+func checkFontInEffect(t *testing.T, content []byte) {
+	t.Helper()
+	selected := false
+	var stack []bool
+	for _, tok := range pdfTokens(content) {
+		switch tok {
+		case "q":
+			stack = append(stack, selected)
+		case "Q":
+			if n := len(stack); n > 0 {
+				selected = stack[n-1]
+				stack = stack[:n-1]
+			}
+		case "Tf":
+			selected = true
+		case "BT":
+			if !selected {
+				t.Fatalf("text object with no font in effect:\n%s", prettyContent(content))
+			}
+		}
+	}
+}
+
+func TestDefaultFontInEffect(t *testing.T) {
+	tests := []struct {
+		name string
+		draw func(p *Page, f *Font)
+	}{
+		{"TextColor", func(p *Page, f *Font) {
+			p.SetTextColor(RGB{1, 0, 0})
+			p.Left(72, 700, "a")
+			p.Left(72, 680, "b")
+		}},
+		{"SaveRestore", func(p *Page, f *Font) {
+			p.Save()
+			p.Left(72, 700, "a")
+			p.Restore()
+			p.Left(72, 680, "b")
+		}},
+		{"SetFontInsideSave", func(p *Page, f *Font) {
+			p.Save()
+			p.SetFont(f, 20)
+			p.Left(72, 700, "a")
+			p.Restore()
+			p.Left(72, 680, "b")
+		}},
+		{"RichLine", func(p *Page, f *Font) {
+			p.RichLine(72, 700, []TextSpan{{Text: "a", Size: 20}})
+			p.Left(72, 680, "b")
+		}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			f := testFont(t)
+			d := new(Document)
+			d.SetDefaultFont(f, 12)
+			p := d.NewPage(612, 792)
+			test.draw(p, f)
+			checkFontInEffect(t, p.pageContent())
+		})
+	}
+}
+
+func TestSetFontInsideText(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.BeginText()
+	p.SetFont(f, 12)
+	p.TextMove(72, 700)
+	p.ShowText("a")
+	p.EndText()
+	p.RichLine(72, 680, []TextSpan{{Text: "b", Font: f, Size: 12}, {Text: "c", Font: f, Size: 14}})
+
+	text, err := d.ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if text != "a\nbc" {
+		t.Errorf("got %q, want %q", text, "a\nbc")
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("ShowText with no font didn't panic")
+		}
+	}()
+	q := d.NewPage(612, 792)
+	q.BeginText()
+	q.ShowText("a")
+}
+
+func TestFitOrTruncate(t *testing.T) {
+	const s = "Hello wide world of text"
+	f := testFont(t)
+	for i := 0; i < 200; i++ {
+		width := 40 + float64(i)*0.37
+		d := new(Document)
+		p := d.NewPage(612, 792)
+		p.SetFont(f, 12)
+		p.FitOrTruncate(72, 700, width, 1, s)
+		if p.currentSize != 12 {
+			t.Fatalf("font size not restored: %g", p.currentSize)
+		}
+		text, err := d.ExtractText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if text != s {
+			t.Errorf("width %g: got %q, want the whole text shrunk to fit", width, text)
+		}
+	}
+}
+
+func TestFitOrTruncateNeverEnlarges(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(testFont(t), 8)
+	p.FitOrTruncate(72, 700, 20, 12, "Some long text that doesn't fit")
+	for _, tok := range pdfTokens(p.pageContent()) {
+		if tok == "12" {
+			t.Fatalf("text enlarged to minSize:\n%s", prettyContent(p.pageContent()))
+		}
+	}
+	text, _ := d.ExtractText()
+	if !strings.HasSuffix(text, "…") && !strings.HasSuffix(text, "...") {
+		t.Errorf("text not truncated: %q", text)
+	}
+}
+
+// tjWidth returns the width (in 1/1000 em) of the text shown by the operands
+// of a TJ operator, from the font's /W advances and the adjustments.
+func tjWidth(t *testing.T, f *Font, tj []string) int {
+	t.Helper()
+	var buffer sfnt.Buffer
+	width := 0
+	for _, item := range tj {
+		if item == "[" || item == "]" {
+			continue
+		}
+		if item[0] == '(' {
+			for _, c := range []byte(unquoteString(item)) {
+				width += f.codeAdvance(&buffer, c)
+			}
+			continue
+		}
+		n, err := strconv.Atoi(item)
+		if err != nil {
+			t.Fatalf("bad TJ item %q", item)
+		}
+		width -= n
+	}
+	return width
+}
+
+// tjOperands returns the operands of each TJ operator in content.
+func tjOperands(content []byte) [][]string {
+	var result [][]string
+	var operands []string
+	for _, tok := range pdfTokens(content) {
+		if !isOperator(tok) {
+			operands = append(operands, tok)
+			continue
+		}
+		if tok == "TJ" {
+			result = append(result, operands)
+		}
+		operands = nil
+	}
+	return result
+}
+
+func TestJustify(t *testing.T) {
+	f := testFont(t)
+	words := strings.Fields("The quick brown fox jumps over the lazy dog")
+	for _, width := range []int{20000, 20001, 23456, 30000} {
+		if got := tjWidth(t, f, f.justify(words, width)); got != width {
+			t.Errorf("justified to %d: TJ array is %d wide", width, got)
+		}
+	}
+
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 10)
+	p.Justify(72, 700, 300, "The quick brown fox jumps over the lazy dog")
+	content := p.pageContent()
+	if bytes.Contains(content, []byte("Tw")) {
+		t.Errorf("justified with word spacing:\n%s", prettyContent(content))
+	}
+	tj := tjOperands(content)
+	if len(tj) != 1 {
+		t.Fatalf("got %d TJ operators, want 1", len(tj))
+	}
+	if got := tjWidth(t, f, tj[0]); got != 30000 {
+		t.Errorf("Justify to 300 at 10 points: TJ array is %d/1000 em wide, want 30000", got)
+	}
+}
+
+func TestWordWrapJustified(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 10)
+	p.SetLeading(12)
+	const s = "Lorem ipsum dolor sit amet, consectetur adipiscing elit, sed do eiusmod tempor incididunt ut labore et dolore magna aliqua."
+	p.WordWrapJustified(72, 700, 150, s)
+	lines := tjOperands(p.pageContent())
+	if len(lines) < 3 {
+		t.Fatalf("got %d lines, want at least 3", len(lines))
+	}
+	for i, tj := range lines[:len(lines)-1] {
+		if got := tjWidth(t, f, tj); got != 15000 {
+			t.Errorf("line %d is %d/1000 em wide, want 15000", i, got)
+		}
+	}
+	if got := tjWidth(t, f, lines[len(lines)-1]); got >= 15000 {
+		t.Errorf("last line is %d/1000 em wide; it shouldn't be justified", got)
+	}
+}
+
+// truncated draws s with Truncate and returns the width of what was drawn
+// (in points, including the character spacing) and the text that extracts.
+func truncated(t *testing.T, f *Font, width, charSpacing float64, s string) (float64, string) {
+	t.Helper()
+	const size = 10
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, size)
+	p.SetCharSpacing(charSpacing)
+	p.Truncate(72, 700, width, s)
+	drawn := 0.0
+	for _, tj := range tjOperands(p.pageContent()) {
+		drawn += float64(tjWidth(t, f, tj)) * 0.001 * size
+		for _, item := range tj {
+			if item[0] == '(' {
+				drawn += charSpacing * float64(len(unquoteString(item)))
+			}
+		}
+	}
+	text, err := d.ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return drawn, text
+}
+
+func TestTruncateClusters(t *testing.T) {
+	f := testFont(t)
+	const s = "Café naïve 👍🏽 👨‍👩‍👧 résumé"
+	prefixes := map[string]bool{"": true}
+	prefix := ""
+	for _, c := range graphemeClusters(s) {
+		prefix += c
+		prefixes[prefix] = true
+	}
+	if !prefixes["Café"] || prefixes["Cafe"] || prefixes["Café 👍"] {
+		t.Fatalf("unexpected grapheme clusters: %q", graphemeClusters(s))
+	}
+
+	for _, charSpacing := range []float64{0, 1.5} {
+		for width := 15.0; width < 300; width += 0.5 {
+			drawn, text := truncated(t, f, width, charSpacing, s)
+			if drawn > width+fitTolerance {
+				t.Errorf("width %g, spacing %g: drew %g points of text", width, charSpacing, drawn)
+			}
+			if text == s {
+				continue
+			}
+			if !strings.HasSuffix(text, "…") {
+				t.Errorf("width %g, spacing %g: %q has no ellipsis", width, charSpacing, text)
+				continue
+			}
+			if !prefixes[strings.TrimSuffix(text, "…")] {
+				t.Errorf("width %g, spacing %g: %q is cut inside a grapheme cluster", width, charSpacing, text)
+			}
+		}
+	}
+}
+
+func TestTruncateEllipsisTooWide(t *testing.T) {
+	f := testFont(t)
+	drawn, text := truncated(t, f, 1, 0, "Some text")
+	if drawn != 0 || text != "" {
+		t.Errorf("drew %q (%g points) in a 1-point width", text, drawn)
+	}
+}
+
+// widthsEntry returns the widths declared in the /Widths array of the
+// (only) font in a PDF file, indexed by character code.
+func widthsEntry(t *testing.T, pdf []byte) map[byte]int {
+	t.Helper()
+	m := regexp.MustCompile(`/FirstChar (\d+) /LastChar \d+\n/Widths \[([\d ]*)\]`).FindSubmatch(pdf)
+	if m == nil {
+		t.Fatal("no /Widths in font dictionary")
+	}
+	first, _ := strconv.Atoi(string(m[1]))
+	widths := make(map[byte]int)
+	for i, w := range strings.Fields(string(m[2])) {
+		widths[byte(first+i)], _ = strconv.Atoi(w)
+	}
+	return widths
+}
+
+func TestWidthsMatchMeasurement(t *testing.T) {
+	const s = "AVATAR Wavy typography, kerning: To, Ty, Yo."
+	for _, hinting := range []font.Hinting{font.HintingNone, font.HintingVertical, font.HintingFull} {
+		f := testFont(t)
+		f.SetHinting(hinting)
+		d := new(Document)
+		d.SetCompression(CompressNone)
+		p := d.NewPage(612, 792)
+		p.SetFont(f, 12)
+		p.Left(72, 700, s)
+		widths := widthsEntry(t, d.Encode())
+
+		tj, width := f.encodeAndKern(s, 0)
+		declared := 0
+		for _, item := range tj {
+			if item[0] == '(' {
+				for _, c := range []byte(unquoteString(item)) {
+					declared += widths[c]
+				}
+				continue
+			}
+			n, _ := strconv.Atoi(item)
+			declared -= n
+		}
+		if declared != width {
+			t.Errorf("hinting %v: measured width %d, but /Widths and kerning add up to %d", hinting, width, declared)
+		}
+	}
+}
+
+func TestMissingGlyphsRecordedOnce(t *testing.T) {
+	f := testFont(t)
+	f.SetMissingGlyphPolicy(MissingGlyphError)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	for i := 0; i < 100; i++ {
+		p.FitOrTruncate(72, 700, 50, 6, "日本 text")
+		p.Left(72, 680, "日本")
+	}
+	if got := string(f.missing.list); got != "日本" {
+		t.Errorf("missing characters recorded as %q", got)
+	}
+	errs := d.Validate()
+	if len(errs) != 1 || !strings.Contains(errs[0].Error(), `"日本"`) {
+		t.Errorf("Validate returned %v", errs)
+	}
+}
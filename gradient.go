@@ -0,0 +1,178 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Gradient is a smooth blend between colors: axial (along a line) or
+// radial (between two circles). Its coordinates are in the user space of
+// the page where it is drawn. All of its colors must be in the same device
+// color space (Gray, RGB, or CMYK). A Gradient can be used on any number of
+// pages.
+type Gradient struct {
+	radial bool
+	coords []float64
+	stops  []gradientStop
+}
+
+type gradientStop struct {
+	offset float64
+	color  Color
+}
+
+// NewLinearGradient returns an axial gradient that blends from the color
+// from at (x0, y0) to the color to at (x1, y1). Beyond the ends, the end
+// colors continue.
+func NewLinearGradient(x0, y0, x1, y1 float64, from, to Color) *Gradient {
+	return &Gradient{
+		coords: []float64{x0, y0, x1, y1},
+		stops:  []gradientStop{{0, from}, {1, to}},
+	}
+}
+
+// NewRadialGradient returns a radial gradient that blends from the color
+// from on the circle centered at (x0, y0) with radius r0 to the color to on
+// the circle centered at (x1, y1) with radius r1. For the usual gradient
+// from the center of a circle outward, use the same center with r0 = 0.
+func NewRadialGradient(x0, y0, r0, x1, y1, r1 float64, from, to Color) *Gradient {
+	return &Gradient{
+		radial: true,
+		coords: []float64{x0, y0, r0, x1, y1, r1},
+		stops:  []gradientStop{{0, from}, {1, to}},
+	}
+}
+
+// AddStop adds an intermediate color to the gradient, at offset (between 0
+// and 1) along the way from the start to the end.
+func (g *Gradient) AddStop(offset float64, c Color) {
+	g.stops = append(g.stops, gradientStop{offset, c})
+	sort.SliceStable(g.stops, func(i, j int) bool { return g.stops[i].offset < g.stops[j].offset })
+}
+
+// deviceColor returns the name of c's color space and its components, if it
+// is a device color.
+func deviceColor(c Color) (space string, components []float64, ok bool) {
+	switch c := c.(type) {
+	case Gray:
+		return "/DeviceGray", []float64{float64(c)}, true
+	case RGB:
+		return "/DeviceRGB", []float64{c.R, c.G, c.B}, true
+	case CMYK:
+		return "/DeviceCMYK", []float64{c.C, c.M, c.Y, c.K}, true
+	}
+	return "", nil, false
+}
+
+// colorSpace returns the color space shared by the gradient's colors.
+func (g *Gradient) colorSpace() (string, error) {
+	var space string
+	for _, stop := range g.stops {
+		s, _, ok := deviceColor(stop.color)
+		if !ok {
+			return "", fmt.Errorf("gradient color %v is not a Gray, RGB, or CMYK color", stop.color)
+		}
+		if space != "" && s != space {
+			return "", fmt.Errorf("gradient mixes color spaces %s and %s", space, s)
+		}
+		space = s
+	}
+	return space, nil
+}
+
+func (g *Gradient) writeTo(e *encoder) {
+	space, _ := g.colorSpace()
+	shadingType := 2
+	if g.radial {
+		shadingType = 3
+	}
+	coords := make([]string, len(g.coords))
+	for i, c := range g.coords {
+		coords[i] = formatNum(c)
+	}
+	fmt.Fprintf(e, "<< /ShadingType %d /ColorSpace %s /Coords [%s] /Extend [true true] /Function %s >>", shadingType, space, strings.Join(coords, " "), g.function())
+}
+
+// function returns the PDF function that maps a position along the
+// gradient (from 0 to 1) to a color.
+func (g *Gradient) function() string {
+	components := func(c Color) string {
+		_, v, _ := deviceColor(c)
+		s := make([]string, len(v))
+		for i, n := range v {
+			s[i] = formatNum(n)
+		}
+		return "[" + strings.Join(s, " ") + "]"
+	}
+	blend := func(a, b Color) string {
+		return fmt.Sprintf("<< /FunctionType 2 /Domain [0 1] /C0 %s /C1 %s /N 1 >>", components(a), components(b))
+	}
+
+	if len(g.stops) == 2 {
+		return blend(g.stops[0].color, g.stops[1].color)
+	}
+	var functions, bounds, encode []string
+	for i := 1; i < len(g.stops); i++ {
+		functions = append(functions, blend(g.stops[i-1].color, g.stops[i].color))
+		encode = append(encode, "0 1")
+		if i < len(g.stops)-1 {
+			bounds = append(bounds, formatNum(g.stops[i].offset))
+		}
+	}
+	return fmt.Sprintf("<< /FunctionType 3 /Domain [0 1] /Functions [%s] /Bounds [%s] /Encode [%s] >>", strings.Join(functions, " "), strings.Join(bounds, " "), strings.Join(encode, " "))
+}
+
+// markPathStart records where the current path begins in the content
+// stream, if a new path is being started.
+func (p *Page) markPathStart() {
+	if !p.pathOpen() {
+		p.pathStream, p.pathStart = p.contents, p.contents.b.Len()
+	}
+}
+
+// pathOpen reports whether a path is under construction: whether anything
+// has been added to a path since it was last painted. Painting operators
+// are the ones followed by a newline in the content stream.
+func (p *Page) pathOpen() bool {
+	return p.pathStream == p.contents && !bytes.ContainsRune(p.contents.b.Bytes()[p.pathStart:], '\n')
+}
+
+// FillGradient fills the current path with g, as Fill fills it with the
+// fill color, and ends the path. The gradient is clipped to the path, and
+// the graphics state is saved and restored around it, so the clipping
+// path is unchanged afterward.
+func (p *Page) FillGradient(g *Gradient) {
+	if !p.pathOpen() {
+		p.problems = append(p.problems, "FillGradient called with no current path")
+		return
+	}
+	if _, err := g.colorSpace(); err != nil {
+		p.problems = append(p.problems, err.Error())
+		p.contents.op("n")
+		return
+	}
+
+	// Insert q before the path, since it isn't allowed in the middle of
+	// one.
+	b := &p.contents.b
+	path := append([]byte(nil), b.Bytes()[p.pathStart:]...)
+	b.Truncate(p.pathStart)
+	p.contents.op("q")
+	b.Write(path)
+	p.contents.op("W")
+	p.contents.op("n")
+
+	id, ok := p.shadings[g]
+	if !ok {
+		if p.shadings == nil {
+			p.shadings = make(map[*Gradient]int)
+		}
+		id = len(p.shadings)
+		p.shadings[g] = id
+	}
+	p.contents.name(fmt.Sprintf("Sh%d", id))
+	p.contents.op("sh")
+	p.contents.op("Q")
+}
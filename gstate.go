@@ -0,0 +1,165 @@
+package pdf
+
+import "fmt"
+
+// An extGState is a graphics state parameter dictionary, for graphics state
+// parameters that can't be set directly with content-stream operators.
+type extGState struct {
+	// entries is the contents of the dictionary, such as "/OP true".
+	entries string
+
+	// id is used to form the resource name, and is the same on every page
+	// that uses the graphics state.
+	id int
+}
+
+func (gs *extGState) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Type /ExtGState %s >>", gs.entries)
+}
+
+// setExtGState applies the graphics state parameters in entries, adding an
+// ExtGState resource to the page if necessary. Graphics state dictionaries
+// are shared by all the pages in a document.
+func (p *Page) setExtGState(entries string) {
+	d := p.doc
+	gs, ok := d.extGStates[entries]
+	if !ok {
+		if d.extGStates == nil {
+			d.extGStates = make(map[string]*extGState)
+		}
+		gs = &extGState{
+			entries: entries,
+			id:      len(d.extGStates),
+		}
+		d.extGStates[entries] = gs
+	}
+
+	if p.extGStates == nil {
+		p.extGStates = make(map[*extGState]bool)
+	}
+	p.extGStates[gs] = true
+
+	p.contents.name(fmt.Sprintf("GS%d", gs.id))
+	p.contents.op("gs")
+}
+
+// SetOverprint sets whether filled and stroked objects overprint the colors
+// underneath them in separations that they don't use, rather than knocking
+// them out.
+func (p *Page) SetOverprint(fill, stroke bool) {
+	p.setExtGState(fmt.Sprintf("/OP %t /op %t", stroke, fill))
+}
+
+// A TextBlack is a way of printing black text in CMYK output, for
+// SetTextBlack.
+type TextBlack int
+
+const (
+	// TextBlackDefault draws text in the current fill color, like
+	// SetTextColor(nil).
+	TextBlackDefault TextBlack = iota
+
+	// TextBlack100K draws text in pure black ink (100% K) with fill
+	// overprinting on, so that the colors underneath aren't knocked out
+	// and slight misregistration doesn't leave white halos around the
+	// letters. This is what is usually wanted for small body text.
+	TextBlack100K
+
+	// TextBlackRich draws text in rich black (60% C, 40% M, 40% Y, and
+	// 100% K), which looks deeper than 100K black but is only suitable
+	// for large text, since small type in four inks is sensitive to
+	// misregistration. It knocks out the colors underneath.
+	TextBlackRich
+)
+
+// SetTextBlack sets the color and overprint settings used for the text
+// drawn by the text methods, as SetTextColor does, to one of the standard
+// ways of printing black text.
+func (p *Page) SetTextBlack(mode TextBlack) {
+	switch mode {
+	case TextBlack100K:
+		p.SetTextColor(CMYK{0, 0, 0, 1})
+		p.textOverprint = true
+	case TextBlackRich:
+		p.SetTextColor(CMYK{0.6, 0.4, 0.4, 1})
+	default:
+		p.SetTextColor(nil)
+	}
+}
+
+// SetOverprintMode sets the overprint mode. In mode 0 (the default), a CMYK
+// color component of zero knocks out the corresponding separation when
+// overprinting; in mode 1, it leaves it unchanged.
+func (p *Page) SetOverprintMode(mode int) {
+	p.setExtGState(fmt.Sprintf("/OPM %d", mode))
+}
+
+// SetStrokeAdjustment turns automatic stroke adjustment on or off. When it
+// is on, the output device adjusts the positions and widths of thin lines to
+// the pixel grid, so that lines that should be the same width are drawn the
+// same width.
+func (p *Page) SetStrokeAdjustment(on bool) {
+	p.setExtGState(fmt.Sprintf("/SA %t", on))
+}
+
+// SetMinLineWidth sets a minimum line width for the document. Line widths
+// set with Page.SetLineWidth that are smaller than w (including 0, which
+// normally means the thinnest line the device can draw) are increased to w.
+func (d *Document) SetMinLineWidth(w float64) {
+	d.minLineWidth = w
+}
+
+// SetFlatness sets the flatness tolerance: the maximum distance (in device
+// pixels) between a curve and the line segments used to approximate it when
+// it is rendered. Valid values range from 0 to 100; 0 uses the output
+// device's default.
+func (p *Page) SetFlatness(flatness float64) {
+	p.contents.num(flatness)
+	p.contents.op("i")
+}
+
+// The standard rendering intents, for SetRenderingIntent.
+const (
+	AbsoluteColorimetric = "AbsoluteColorimetric"
+	RelativeColorimetric = "RelativeColorimetric"
+	Saturation           = "Saturation"
+	Perceptual           = "Perceptual"
+)
+
+// SetRenderingIntent sets the rendering intent, which controls how colors
+// are converted to the output device's color space. It should be one of the
+// four standard intents: AbsoluteColorimetric, RelativeColorimetric,
+// Saturation, or Perceptual. Any other name is ignored, and reported by
+// Document.Validate.
+func (p *Page) SetRenderingIntent(intent string) {
+	switch intent {
+	case AbsoluteColorimetric, RelativeColorimetric, Saturation, Perceptual:
+		p.contents.name(intent)
+		p.contents.op("ri")
+	default:
+		p.problems = append(p.problems, fmt.Sprintf("unknown rendering intent %q", intent))
+	}
+}
+
+// blendModes lists the standard blend modes.
+var blendModes = map[string]bool{
+	"Normal": true, "Compatible": true, "Multiply": true, "Screen": true,
+	"Overlay": true, "Darken": true, "Lighten": true, "ColorDodge": true,
+	"ColorBurn": true, "HardLight": true, "SoftLight": true, "Difference": true,
+	"Exclusion": true, "Hue": true, "Saturation": true, "Color": true,
+	"Luminosity": true,
+}
+
+// SetBlendMode sets the blend mode, which controls how the colors of
+// objects are combined with the colors already on the page underneath them.
+// It should be one of the standard blend modes: Normal (the default),
+// Multiply, Screen, Overlay, Darken, Lighten, ColorDodge, ColorBurn,
+// HardLight, SoftLight, Difference, Exclusion, Hue, Saturation, Color, or
+// Luminosity. Any other name is ignored, and reported by Document.Validate.
+func (p *Page) SetBlendMode(mode string) {
+	if !blendModes[mode] {
+		p.problems = append(p.problems, fmt.Sprintf("unknown blend mode %q", mode))
+		return
+	}
+	p.setExtGState("/BM /" + mode)
+}
@@ -0,0 +1,299 @@
+package pdf
+
+import (
+	"fmt"
+	"io"
+	"io/ioutil"
+	"regexp"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// A Hyphenator finds the places where words may be hyphenated.
+type Hyphenator interface {
+	// Hyphenate returns the byte offsets in word where it may be broken
+	// with a hyphen, in increasing order.
+	Hyphenate(word string) []int
+}
+
+// A HyphenatorFunc is a function that can be used as a Hyphenator.
+type HyphenatorFunc func(word string) []int
+
+func (f HyphenatorFunc) Hyphenate(word string) []int {
+	return f(word)
+}
+
+// Patterns is a Hyphenator that uses Liang's algorithm (the one used by
+// TeX), with a set of hyphenation patterns for a particular language.
+type Patterns struct {
+	// LeftMin and RightMin are the minimum number of letters that must be
+	// left before and after a hyphen.
+	LeftMin, RightMin int
+
+	// patterns maps the letters of each pattern to its values, which
+	// apply to the positions before, between, and after the letters.
+	patterns map[string][]int
+	maxLen   int
+
+	// exceptions maps words to their hyphenation points, as letter
+	// offsets.
+	exceptions map[string][]int
+}
+
+var texCommand = regexp.MustCompile(`\\(patterns|hyphenation)\s*\{([^}]*)\}`)
+
+// ParsePatterns reads hyphenation patterns in TeX format: either a file
+// with \patterns{...} (and optionally \hyphenation{...}) commands, or a
+// plain list of patterns separated by whitespace, as distributed in the
+// hyph-utf8 project's .pat.txt files. LeftMin and RightMin default to 2
+// and 3, the values used for English.
+func ParsePatterns(r io.Reader) (*Patterns, error) {
+	data, err := ioutil.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+
+	var lines []string
+	for _, line := range strings.Split(string(data), "\n") {
+		if i := strings.IndexByte(line, '%'); i != -1 {
+			line = line[:i]
+		}
+		lines = append(lines, line)
+	}
+	text := strings.Join(lines, "\n")
+
+	p := &Patterns{
+		LeftMin:    2,
+		RightMin:   3,
+		patterns:   make(map[string][]int),
+		exceptions: make(map[string][]int),
+	}
+
+	commands := texCommand.FindAllStringSubmatch(text, -1)
+	if commands == nil {
+		if strings.Contains(text, `\`) {
+			return nil, fmt.Errorf("unrecognized hyphenation pattern file format")
+		}
+		commands = [][]string{{"", "patterns", text}}
+	}
+
+	for _, c := range commands {
+		for _, item := range strings.Fields(c[2]) {
+			if c[1] == "hyphenation" {
+				p.addException(item)
+			} else {
+				p.addPattern(item)
+			}
+		}
+	}
+	if len(p.patterns) == 0 {
+		return nil, fmt.Errorf("no hyphenation patterns found")
+	}
+	return p, nil
+}
+
+// addPattern adds a pattern such as "a1b2c", which applies a value of 1
+// between a and b, and 2 between b and c.
+func (p *Patterns) addPattern(pattern string) {
+	var letters []rune
+	values := []int{0}
+	for _, r := range pattern {
+		if r >= '0' && r <= '9' {
+			values[len(values)-1] = int(r - '0')
+			continue
+		}
+		letters = append(letters, unicode.ToLower(r))
+		values = append(values, 0)
+	}
+	p.patterns[string(letters)] = values
+	if len(letters) > p.maxLen {
+		p.maxLen = len(letters)
+	}
+}
+
+// addException adds a word with its hyphenation points marked with
+// hyphens, such as "ta-ble".
+func (p *Patterns) addException(word string) {
+	var letters []rune
+	var points []int
+	for _, r := range word {
+		if r == '-' {
+			points = append(points, len(letters))
+			continue
+		}
+		letters = append(letters, unicode.ToLower(r))
+	}
+	p.exceptions[string(letters)] = points
+}
+
+// Hyphenate returns the byte offsets where word may be hyphenated. Leading
+// and trailing punctuation is ignored; words that contain other
+// non-letters are not hyphenated.
+func (p *Patterns) Hyphenate(word string) []int {
+	start := strings.IndexFunc(word, unicode.IsLetter)
+	end := strings.LastIndexFunc(word, unicode.IsLetter)
+	if start == -1 {
+		return nil
+	}
+	_, size := utf8.DecodeRuneInString(word[end:])
+	core := word[start : end+size]
+
+	var letters []rune
+	var offsets []int // the byte offset of each letter in word
+	for i, r := range core {
+		if !unicode.IsLetter(r) {
+			return nil
+		}
+		letters = append(letters, unicode.ToLower(r))
+		offsets = append(offsets, start+i)
+	}
+	if len(letters) < p.LeftMin+p.RightMin {
+		return nil
+	}
+
+	var breaks []int // letter offsets
+	if points, ok := p.exceptions[string(letters)]; ok {
+		breaks = points
+	} else {
+		dotted := make([]rune, 0, len(letters)+2)
+		dotted = append(dotted, '.')
+		dotted = append(dotted, letters...)
+		dotted = append(dotted, '.')
+
+		values := make([]int, len(dotted)+1)
+		for i := range dotted {
+			for l := 1; l <= p.maxLen && i+l <= len(dotted); l++ {
+				pattern, ok := p.patterns[string(dotted[i:i+l])]
+				if !ok {
+					continue
+				}
+				for j, v := range pattern {
+					if v > values[i+j] {
+						values[i+j] = v
+					}
+				}
+			}
+		}
+		// The position before letters[k] is values[k+1].
+		for k := 1; k < len(letters); k++ {
+			if values[k+1]%2 == 1 {
+				breaks = append(breaks, k)
+			}
+		}
+	}
+
+	var result []int
+	for _, k := range breaks {
+		if k >= p.LeftMin && len(letters)-k >= p.RightMin {
+			result = append(result, offsets[k])
+		}
+	}
+	return result
+}
+
+// hyphenationLanguages holds the Hyphenators registered with
+// RegisterHyphenator.
+var hyphenationLanguages = make(map[string]Hyphenator)
+
+// RegisterHyphenator makes h available for use with
+// Document.SetHyphenationLanguage, for the language lang (such as "en").
+func RegisterHyphenator(lang string, h Hyphenator) {
+	hyphenationLanguages[strings.ToLower(lang)] = h
+}
+
+// SetHyphenator sets the Hyphenator used by WordWrapHyphenated.
+func (d *Document) SetHyphenator(h Hyphenator) {
+	d.hyphenator = h
+}
+
+// SetHyphenationLanguage sets the Hyphenator used by WordWrapHyphenated to
+// one registered for lang with RegisterHyphenator. Patterns for English
+// ("en") are built in. If none is registered for lang, it tries the primary
+// language subtag (for example, "en" for "en-US"), and then returns an
+// error.
+func (d *Document) SetHyphenationLanguage(lang string) error {
+	lang = strings.ToLower(lang)
+	h, ok := hyphenationLanguages[lang]
+	if !ok {
+		if i := strings.IndexByte(lang, '-'); i != -1 {
+			h, ok = hyphenationLanguages[lang[:i]]
+		}
+	}
+	if !ok {
+		return fmt.Errorf("no hyphenation patterns registered for %q", lang)
+	}
+	d.hyphenator = h
+	return nil
+}
+
+// WordWrapHyphenated displays s like WordWrap, but when the next word
+// doesn't fit on a line, it uses the document's Hyphenator (set with
+// SetHyphenator or SetHyphenationLanguage) to fit as much of the word as
+// possible on the line, followed by a hyphen. Without a Hyphenator, it is
+// the same as WordWrap.
+func (p *Page) WordWrapHyphenated(x, y, margin float64, s string) {
+	h := p.doc.hyphenator
+	if h == nil {
+		p.WordWrap(x, y, margin, s)
+		return
+	}
+	p.beginTag("P")
+	p.beginText()
+	p.TextMove(x, y)
+	for i, line := range p.font().hyphenatedLines(s, int(margin/p.currentSize*1000), h) {
+		if i > 0 {
+			p.TextNextLine()
+		}
+		p.showTJ(line.tj)
+	}
+	p.endText()
+	p.endTag()
+}
+
+// hyphenatedLines splits s into lines, wrapping at word boundaries to keep
+// the width of each line (in 1/1000 em) no more than maxWidth. If h is not
+// nil, it also hyphenates the word following each line if part of it will
+// fit.
+func (f *Font) hyphenatedLines(s string, maxWidth int, h Hyphenator) []wrappedLine {
+	var lines []wrappedLine
+	words := strings.Fields(s)
+	for len(words) > 0 {
+		line, lineWidth := f.encodeAndKern(words[0], 0)
+		n := 1
+		for n < len(words) {
+			word, wordWidth := f.encodeAndKern(" "+words[n], 0)
+			if lineWidth+wordWidth > maxWidth {
+				break
+			}
+			line = append(line, word...)
+			lineWidth += wordWidth
+			n++
+		}
+		lineWords := append([]string(nil), words[:n]...)
+
+		if n < len(words) && h != nil {
+			next := words[n]
+			points := h.Hyphenate(next)
+			for i := len(points) - 1; i >= 0; i-- {
+				head := next[:points[i]] + "-"
+				part, partWidth := f.encodeAndKern(" "+head, 0)
+				if lineWidth+partWidth <= maxWidth {
+					line = append(line, part...)
+					lineWidth += partWidth
+					lineWords = append(lineWords, head)
+					words[n] = next[points[i]:]
+					break
+				}
+			}
+		}
+
+		lines = append(lines, wrappedLine{
+			words: lineWords,
+			tj:    line,
+			width: lineWidth,
+		})
+		words = words[n:]
+	}
+	return lines
+}
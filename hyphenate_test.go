@@ -0,0 +1,54 @@
+package pdf
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// hyphenated returns word with hyphens inserted at the points found by h.
+func hyphenated(h Hyphenator, word string) string {
+	var b strings.Builder
+	last := 0
+	for _, i := range h.Hyphenate(word) {
+		b.WriteString(word[last:i])
+		b.WriteByte('-')
+		last = i
+	}
+	b.WriteString(word[last:])
+	return b.String()
+}
+
+func TestBuiltInEnglishPatterns(t *testing.T) {
+	d := new(Document)
+	if err := d.SetHyphenationLanguage("en-US"); err != nil {
+		t.Fatal(err)
+	}
+	for _, want := range []string{
+		"hy-phen-a-tion",
+		"ta-ble",
+		"com-puter",
+		"as-so-ciate",
+		"Ty-pog-ra-phy",
+		"en-cy-clo-pe-dia",
+		"acad-e-mies", // an exception
+	} {
+		word := strings.Replace(want, "-", "", -1)
+		if got := hyphenated(d.hyphenator, word); got != want {
+			t.Errorf("%s: got %s, want %s", word, got, want)
+		}
+	}
+}
+
+func TestHyphenationLanguageFallback(t *testing.T) {
+	d := new(Document)
+	if err := d.SetHyphenationLanguage("xx-YY"); err == nil {
+		t.Error("no error for a language with no patterns")
+	}
+	if err := d.SetHyphenationLanguage("EN"); err != nil {
+		t.Error(err)
+	}
+	if !reflect.DeepEqual(d.hyphenator, hyphenationLanguages["en"]) {
+		t.Error("SetHyphenationLanguage(\"EN\") didn't select the English patterns")
+	}
+}
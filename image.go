@@ -0,0 +1,434 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/color"
+	_ "image/gif"
+	_ "image/jpeg"
+	_ "image/png"
+	"io/ioutil"
+	"math"
+)
+
+// An Image is a raster image that can be drawn on a page.
+type Image struct {
+	width, height    int
+	colorSpace       string
+	bitsPerComponent int
+	data             *stream
+	sMask            *stream
+
+	// imageMask is set for a stencil mask made by NewImageMask; mask is a
+	// stencil mask set with SetMask.
+	imageMask bool
+	mask      *Image
+
+	// downsampled holds the copies made for SetMaxImageDPI.
+	downsampled map[image.Point]*Image
+}
+
+// LoadImage loads a JPEG, PNG, or GIF image from the file specified. JPEG
+// files are embedded without being decoded and recompressed. If the file has
+// already been loaded into this Document, the previous instance is returned.
+func (d *Document) LoadImage(filename string) (*Image, error) {
+	if img, ok := d.imageCache[filename]; ok {
+		return img, nil
+	}
+
+	b, err := ioutil.ReadFile(filename)
+	if err != nil {
+		return nil, err
+	}
+
+	config, format, err := image.DecodeConfig(bytes.NewReader(b))
+	if err != nil {
+		return nil, err
+	}
+
+	var img *Image
+	if format == "jpeg" && (config.ColorModel == color.GrayModel || config.ColorModel == color.YCbCrModel) {
+		img = &Image{
+			width:            config.Width,
+			height:           config.Height,
+			colorSpace:       "/DeviceRGB",
+			bitsPerComponent: 8,
+			data:             &stream{filter: "/DCTDecode"},
+		}
+		if config.ColorModel == color.GrayModel {
+			img.colorSpace = "/DeviceGray"
+		}
+		img.data.b.Write(b)
+	} else {
+		decoded, _, err := image.Decode(bytes.NewReader(b))
+		if err != nil {
+			return nil, err
+		}
+		img = NewImage(decoded)
+	}
+
+	if d.imageCache == nil {
+		d.imageCache = make(map[string]*Image)
+	}
+	d.imageCache[filename] = img
+	return img, nil
+}
+
+// NewImage converts img to an Image that can be drawn on a page. If img has
+// transparent pixels, its alpha channel is used as a soft mask.
+//
+// The color space of the Image depends on the type of img: *image.Gray
+// becomes DeviceGray (with 1 bit per pixel if every pixel is black or
+// white), *image.CMYK becomes DeviceCMYK, and an opaque *image.Paletted
+// becomes an Indexed color space (or 1-bit DeviceGray, if its palette is
+// just black and white). Anything else becomes DeviceRGB.
+func NewImage(img image.Image) *Image {
+	switch img := img.(type) {
+	case *image.Gray:
+		if isBilevel(img.Pix) {
+			return newBilevelImage(img.Bounds(), func(x, y int) bool { return img.GrayAt(x, y).Y != 0 })
+		}
+		return newRawImage(img.Bounds(), "/DeviceGray", func(b *bytes.Buffer, x, y int) {
+			b.WriteByte(img.GrayAt(x, y).Y)
+		})
+	case *image.CMYK:
+		return newRawImage(img.Bounds(), "/DeviceCMYK", func(b *bytes.Buffer, x, y int) {
+			c := img.CMYKAt(x, y)
+			b.Write([]byte{c.C, c.M, c.Y, c.K})
+		})
+	case *image.Paletted:
+		if result := newPalettedImage(img); result != nil {
+			return result
+		}
+	}
+
+	bounds := img.Bounds()
+	result := &Image{
+		width:            bounds.Dx(),
+		height:           bounds.Dy(),
+		colorSpace:       "/DeviceRGB",
+		bitsPerComponent: 8,
+		data:             new(stream),
+	}
+
+	alpha := new(stream)
+	opaque := true
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(img.At(x, y)).(color.NRGBA)
+			result.data.b.Write([]byte{c.R, c.G, c.B})
+			alpha.b.WriteByte(c.A)
+			if c.A != 0xff {
+				opaque = false
+			}
+		}
+	}
+
+	if !opaque {
+		alpha.extraData = fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace /DeviceGray /BitsPerComponent 8", result.width, result.height)
+		result.sMask = alpha
+	}
+	return result
+}
+
+// newRawImage returns an opaque Image with 8 bits per component, in
+// colorSpace, with the samples for each pixel written by pixel.
+func newRawImage(bounds image.Rectangle, colorSpace string, pixel func(b *bytes.Buffer, x, y int)) *Image {
+	result := &Image{
+		width:            bounds.Dx(),
+		height:           bounds.Dy(),
+		colorSpace:       colorSpace,
+		bitsPerComponent: 8,
+		data:             new(stream),
+	}
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			pixel(&result.data.b, x, y)
+		}
+	}
+	return result
+}
+
+// isBilevel reports whether all the values in pix are 0 or 255.
+func isBilevel(pix []byte) bool {
+	for _, v := range pix {
+		if v != 0 && v != 0xff {
+			return false
+		}
+	}
+	return true
+}
+
+// newBilevelImage returns a 1-bit DeviceGray Image, with the pixels for
+// which white returns true set to white, and the others black. The image
+// data is compressed with CCITT Group 4 encoding, which works much better
+// than Flate for scanned documents.
+func newBilevelImage(bounds image.Rectangle, white func(x, y int) bool) *Image {
+	result := &Image{
+		width:            bounds.Dx(),
+		height:           bounds.Dy(),
+		colorSpace:       "/DeviceGray",
+		bitsPerComponent: 1,
+		data:             new(stream),
+	}
+	// Each row starts on a byte boundary.
+	row := make([]byte, (result.width+7)/8)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for i := range row {
+			row[i] = 0
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if white(x, y) {
+				i := x - bounds.Min.X
+				row[i/8] |= 0x80 >> uint(i%8)
+			}
+		}
+		result.data.b.Write(row)
+	}
+
+	g4 := encodeG4(result.data.b.Bytes(), result.width, result.height)
+	result.data.b.Reset()
+	result.data.b.Write(g4)
+	result.data.filter = "/CCITTFaxDecode"
+	result.data.decodeParms = fmt.Sprintf("<< /K -1 /Columns %d /Rows %d /BlackIs1 false >>", result.width, result.height)
+	return result
+}
+
+// newPalettedImage converts img to an Image with an Indexed color space,
+// or to a bilevel image if its palette is just black and white. If the
+// palette has any transparent colors, it returns nil.
+func newPalettedImage(img *image.Paletted) *Image {
+	bw := true
+	for _, c := range img.Palette {
+		r, g, b, a := c.RGBA()
+		if a != 0xffff {
+			return nil
+		}
+		if !(r == g && g == b && (r == 0 || r == 0xffff)) {
+			bw = false
+		}
+	}
+	if len(img.Palette) == 0 || len(img.Palette) > 256 {
+		return nil
+	}
+	if bw {
+		return newBilevelImage(img.Bounds(), func(x, y int) bool {
+			r, _, _, _ := img.At(x, y).RGBA()
+			return r != 0
+		})
+	}
+
+	palette := new(bytes.Buffer)
+	for _, c := range img.Palette {
+		rgb := color.NRGBAModel.Convert(c).(color.NRGBA)
+		fmt.Fprintf(palette, "%02X%02X%02X", rgb.R, rgb.G, rgb.B)
+	}
+	colorSpace := fmt.Sprintf("[/Indexed /DeviceRGB %d <%s>]", len(img.Palette)-1, palette)
+	return newRawImage(img.Bounds(), colorSpace, func(b *bytes.Buffer, x, y int) {
+		b.WriteByte(img.ColorIndexAt(x, y))
+	})
+}
+
+// Width returns the width of the image, in pixels.
+func (img *Image) Width() int {
+	return img.width
+}
+
+// Height returns the height of the image, in pixels.
+func (img *Image) Height() int {
+	return img.height
+}
+
+func (img *Image) writeTo(e *encoder) {
+	if img.imageMask {
+		img.data.extraData = fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ImageMask true /BitsPerComponent 1", img.width, img.height)
+	} else {
+		img.data.extraData = fmt.Sprintf("/Type /XObject /Subtype /Image /Width %d /Height %d /ColorSpace %s /BitsPerComponent %d", img.width, img.height, img.colorSpace, img.bitsPerComponent)
+	}
+	if img.sMask != nil {
+		img.data.extraData += fmt.Sprintf(" /SMask %d 0 R", e.getRef(img.sMask))
+	}
+	if img.mask != nil {
+		img.data.extraData += fmt.Sprintf(" /Mask %d 0 R", e.getRef(img.mask))
+	}
+	img.data.writeTo(e)
+}
+
+// NewImageMask converts img to a stencil mask: a 1-bit image that marks
+// which pixels are painted. A pixel is painted if it is darker than 50%
+// gray when composited over white, so both a black shape on a transparent
+// background and a black-and-white image work as expected.
+//
+// Drawing a stencil mask with DrawImage paints its pixels in the current
+// fill color, which makes it a compact way to draw icons that can be
+// drawn in any color. A stencil mask can also be used with SetMask.
+func NewImageMask(img image.Image) *Image {
+	result := newBilevelImage(img.Bounds(), func(x, y int) bool {
+		r, g, b, a := img.At(x, y).RGBA()
+		// Composite over white, and compute the luminance.
+		r, g, b = r+0xffff-a, g+0xffff-a, b+0xffff-a
+		return 299*r+587*g+114*b >= 1000*0x8000
+	})
+	result.imageMask = true
+	return result
+}
+
+// SetMask sets a stencil mask (from NewImageMask) for img. Only the parts
+// of img where the mask is painted are drawn; the rest is left transparent.
+// The mask is stretched to cover the image, so it doesn't need to be the
+// same size.
+func (img *Image) SetMask(mask *Image) {
+	img.mask = mask
+}
+
+// imageID returns the number used in the resource name of img on p, adding
+// it to the page's resources if necessary.
+func (p *Page) imageID(img *Image) int {
+	imageID, ok := p.images[img]
+	if !ok {
+		if p.images == nil {
+			p.images = make(map[*Image]int)
+		}
+		imageID = len(p.images)
+		p.images[img] = imageID
+	}
+	return imageID
+}
+
+// drawXObject draws the XObject with the given resource name, transformed by
+// the matrix [a b c d e f].
+func (p *Page) drawXObject(name string, a, b, c, d, e, f float64) {
+	p.contents.op("q")
+	p.contents.num(a, b, c, d, e, f)
+	p.contents.op("cm")
+	p.contents.name(name)
+	p.contents.op("Do")
+	p.contents.op("Q")
+}
+
+// drawImageXObject is like drawXObject, but if the page is in top-down
+// mode, it flips the image's unit square so that the image is right side
+// up.
+func (p *Page) drawImageXObject(name string, a, b, c, d, e, f float64) {
+	if p.topDown {
+		c, d, e, f = -c, -d, c+e, d+f
+	}
+	p.drawXObject(name, a, b, c, d, e, f)
+}
+
+// DrawImage draws img with its lower left corner at (x, y), scaled to width
+// and height.
+func (p *Page) DrawImage(img *Image, x, y, width, height float64) {
+	img = p.limitResolution(img, width, height)
+	imageID := p.imageID(img)
+	p.drawImageXObject(fmt.Sprintf("Im%d", imageID), width, 0, 0, height, x, y)
+}
+
+// DrawImageRotated draws img like DrawImage, scaled to width and height with
+// its lower left corner at (x, y), but then rotated counterclockwise by the
+// given angle (in degrees) around the center of that rectangle.
+func (p *Page) DrawImageRotated(img *Image, x, y, width, height, degrees float64) {
+	img = p.limitResolution(img, width, height)
+	imageID := p.imageID(img)
+	sin, cos := math.Sincos(degrees * math.Pi / 180)
+	cx, cy := x+width/2, y+height/2
+	e := cx - width/2*cos + height/2*sin
+	f := cy - width/2*sin - height/2*cos
+	p.drawImageXObject(fmt.Sprintf("Im%d", imageID), width*cos, width*sin, -height*sin, height*cos, e, f)
+}
+
+// DrawImageFit draws img as large as possible within the box with its lower
+// left corner at (x, y), while preserving its aspect ratio. The image is
+// centered in the box. An image with no pixels has no aspect ratio, so it
+// isn't drawn, and the problem is reported by Document.Validate.
+func (p *Page) DrawImageFit(img *Image, x, y, maxWidth, maxHeight float64) {
+	if img.width == 0 || img.height == 0 {
+		p.problems = append(p.problems, fmt.Sprintf("DrawImageFit called with a %dx%d image", img.width, img.height))
+		return
+	}
+	scale := maxWidth / float64(img.width)
+	if s := maxHeight / float64(img.height); s < scale {
+		scale = s
+	}
+	width := float64(img.width) * scale
+	height := float64(img.height) * scale
+	p.DrawImage(img, x+(maxWidth-width)/2, y+(maxHeight-height)/2, width, height)
+}
+
+// SetImageDPI sets the resolution that DrawImageAt uses for an image's
+// natural size. The default is 72, which draws each pixel as one point; a
+// 300 DPI scan should be drawn at 300, so that it comes out at its original
+// physical size.
+func (d *Document) SetImageDPI(dpi float64) {
+	d.imageDPI = dpi
+}
+
+// DrawImageAt draws img at its natural size (by default, one pixel per
+// point, or 72 DPI; see SetImageDPI), with its lower left corner at (x, y).
+func (p *Page) DrawImageAt(img *Image, x, y float64) {
+	scale := 1.0
+	if dpi := p.doc.imageDPI; dpi > 0 {
+		scale = 72 / dpi
+	}
+	p.DrawImage(img, x, y, float64(img.width)*scale, float64(img.height)*scale)
+}
+
+// An ImageScaling specifies how SetBackgroundImage fits an image to the
+// page.
+type ImageScaling int
+
+const (
+	// ScaleStretch stretches the image to fill the page exactly, changing
+	// its aspect ratio if necessary.
+	ScaleStretch ImageScaling = iota
+
+	// ScaleFit makes the image as large as possible while keeping its
+	// aspect ratio and showing all of it, centered on the page.
+	ScaleFit
+
+	// ScaleFill makes the image just large enough to cover the whole page
+	// while keeping its aspect ratio, centered on the page. The parts that
+	// extend beyond the page are clipped.
+	ScaleFill
+)
+
+// SetBackgroundImage draws img behind all of the page's other content,
+// scaled to cover the media box as specified by scaling. It is meant for
+// things like a scanned form to be filled in. Since it is drawn in a
+// separate content stream at the beginning of the page, it doesn't affect
+// the graphics state for other drawing. Calling it again replaces the
+// background image, and a nil img removes it. Like the headers and footers,
+// the background is drawn when the document is encoded.
+func (p *Page) SetBackgroundImage(img *Image, scaling ImageScaling) {
+	p.backgroundImage, p.backgroundScaling = img, scaling
+}
+
+// drawBackground draws the page's background image, if it has one, in its
+// own content stream.
+func (p *Page) drawBackground() {
+	p.background = nil
+	img, scaling := p.backgroundImage, p.backgroundScaling
+	if img == nil {
+		return
+	}
+	box := p.MediaBox()
+	w, h := box.X1-box.X0, box.Y1-box.Y0
+	p.background = p.drawFragment(func() {
+		switch scaling {
+		case ScaleFit:
+			p.DrawImageFit(img, box.X0, box.Y0, w, h)
+		case ScaleFill:
+			scale := w / float64(img.width)
+			if s := h / float64(img.height); s > scale {
+				scale = s
+			}
+			width := float64(img.width) * scale
+			height := float64(img.height) * scale
+			p.ClipRect(box.X0, box.Y0, w, h)
+			p.DrawImage(img, box.X0+(w-width)/2, box.Y0+(h-height)/2, width, height)
+		default:
+			p.DrawImage(img, box.X0, box.Y0, w, h)
+		}
+	})
+}
@@ -0,0 +1,87 @@
+package pdf
+
+import (
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestImagePixels(t *testing.T) {
+	r := image.Rect(0, 0, 13, 7)
+	gray := image.NewGray(r)
+	bilevel := image.NewGray(r)
+	cmyk := image.NewCMYK(r)
+	nrgba := image.NewNRGBA(r)
+	paletted := image.NewPaletted(r, color.Palette{color.RGBA{200, 0, 0, 255}, color.RGBA{0, 100, 50, 255}, color.RGBA{1, 2, 3, 255}})
+	for y := 0; y < r.Dy(); y++ {
+		for x := 0; x < r.Dx(); x++ {
+			gray.SetGray(x, y, color.Gray{uint8(x * y * 3)})
+			if (x+y)%3 == 0 {
+				bilevel.SetGray(x, y, color.Gray{0xff})
+			}
+			cmyk.SetCMYK(x, y, color.CMYK{uint8(x * 10), uint8(y * 20), 30, 40})
+			nrgba.SetNRGBA(x, y, color.NRGBA{uint8(x * 10), uint8(y * 20), 30, uint8(255 - x*y)})
+			paletted.SetColorIndex(x, y, uint8((x+y)%3))
+		}
+	}
+
+	for _, test := range []struct {
+		name string
+		img  image.Image
+	}{
+		{"Gray", gray},
+		{"Bilevel", bilevel},
+		{"CMYK", cmyk},
+		{"NRGBA", nrgba},
+		{"Paletted", paletted},
+	} {
+		decoded := NewImage(test.img).pixels()
+		if decoded == nil {
+			t.Errorf("%s: pixels not decoded", test.name)
+			continue
+		}
+		if decoded.Bounds() != r {
+			t.Errorf("%s: bounds %v, want %v", test.name, decoded.Bounds(), r)
+			continue
+		}
+	compare:
+		for y := 0; y < r.Dy(); y++ {
+			for x := 0; x < r.Dx(); x++ {
+				want := color.NRGBAModel.Convert(test.img.At(x, y))
+				if got := color.NRGBAModel.Convert(decoded.At(x, y)); got != want {
+					t.Errorf("%s: pixel (%d, %d) is %v, want %v", test.name, x, y, got, want)
+					break compare
+				}
+			}
+		}
+	}
+}
+
+func TestDrawImageFitEmpty(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.DrawImageFit(NewImage(image.NewGray(image.Rect(0, 0, 0, 0))), 72, 72, 100, 100)
+	if strings.Contains(string(p.pageContent()), "NaN") {
+		t.Errorf("NaN in content stream:\n%s", p.pageContent())
+	}
+	if errs := d.Validate(); len(errs) != 1 {
+		t.Errorf("got errors %v, want one for the empty image", errs)
+	}
+}
+
+func TestSetBackgroundImageReplaces(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	first := NewImage(image.NewGray(image.Rect(0, 0, 3, 3)))
+	second := NewImage(image.NewGray(image.Rect(0, 0, 4, 4)))
+	p.SetBackgroundImage(first, ScaleStretch)
+	p.SetBackgroundImage(second, ScaleFit)
+	out := string(d.Encode())
+	if n := strings.Count(out, "/Subtype /Image"); n != 1 {
+		t.Errorf("%d images in the file, want 1", n)
+	}
+	if n := strings.Count(string(p.pageContent()), " Do"); n != 1 {
+		t.Errorf("%d images drawn, want 1", n)
+	}
+}
@@ -0,0 +1,375 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// An InvoiceItem is a line item on an Invoice.
+type InvoiceItem struct {
+	Description string
+	Quantity    float64
+	UnitPrice   float64
+}
+
+// Amount returns the item's quantity times its unit price.
+func (item InvoiceItem) Amount() float64 {
+	return item.Quantity * item.UnitPrice
+}
+
+// An Invoice lays out an invoice or receipt in a fixed, simple style: a
+// header with the logo, title, and details such as the invoice number; the
+// sender's and recipient's addresses; a table of line items; the subtotal,
+// tax, and total; notes; and a footer on each page. Long lists of items are
+// continued on more pages, with the column headings repeated.
+//
+// Only Font is required; the other fields are left out if they are empty.
+type Invoice struct {
+	// Font is used for the text, and BoldFont for headings and the total.
+	// If BoldFont is nil, Font is used instead.
+	Font, BoldFont *Font
+
+	// Size is the font size for most of the text. The default is 10.
+	Size float64
+
+	// Margin is the space around the edges of each page. The default is
+	// 54 (3/4 inch).
+	Margin float64
+
+	Logo *Image
+
+	// Title is the large heading at the top of the first page. The default
+	// is "Invoice".
+	Title string
+
+	// Details are label-value pairs, such as the invoice number and date,
+	// listed under the title.
+	Details [][2]string
+
+	// From is the sender's name and address, and To is the recipient's,
+	// with lines separated by newlines.
+	From, To string
+
+	Items []InvoiceItem
+
+	// TaxRate is the tax charged on the subtotal (such as 0.08 for 8%), and
+	// TaxLabel is its label. The default label is "Tax".
+	TaxRate  float64
+	TaxLabel string
+
+	// Currency is written before each amount, such as "$".
+	Currency string
+
+	Notes  string
+	Footer string
+}
+
+// Subtotal returns the sum of the amounts of the items, each rounded to the
+// nearest cent as it is printed, so that the printed amounts add up.
+func (inv *Invoice) Subtotal() float64 {
+	var sum float64
+	for _, item := range inv.Items {
+		sum += roundCents(item.Amount())
+	}
+	return roundCents(sum)
+}
+
+// Tax returns the tax on the subtotal, rounded to the nearest cent.
+func (inv *Invoice) Tax() float64 {
+	return roundCents(inv.Subtotal() * inv.TaxRate)
+}
+
+// Total returns the subtotal plus the tax.
+func (inv *Invoice) Total() float64 {
+	return roundCents(inv.Subtotal() + inv.Tax())
+}
+
+func roundCents(x float64) float64 {
+	return math.Round(x*100) / 100
+}
+
+// formatAmount formats x with two decimal places and thousands separators,
+// after the invoice's currency symbol.
+func (inv *Invoice) formatAmount(x float64) string {
+	s := strconv.FormatFloat(math.Abs(x), 'f', 2, 64)
+	whole, cents := s[:len(s)-3], s[len(s)-3:]
+	var b strings.Builder
+	if x < 0 && s != "0.00" {
+		b.WriteByte('-')
+	}
+	b.WriteString(inv.Currency)
+	for i, c := range whole {
+		if i > 0 && (len(whole)-i)%3 == 0 {
+			b.WriteByte(',')
+		}
+		b.WriteRune(c)
+	}
+	b.WriteString(cents)
+	return b.String()
+}
+
+// An invoiceLayout holds the state of an Invoice while it is being drawn.
+type invoiceLayout struct {
+	inv           *Invoice
+	doc           *Document
+	width, height float64
+	font, bold    *Font
+	size, leading float64
+
+	// left, right, top, and bottom are the edges of the space for content.
+	left, right, top, bottom float64
+
+	// Column positions for the items table: descWidth is the width of the
+	// description column, and the others are the right edges of the
+	// number columns.
+	descWidth           float64
+	qtyX, unitX, amount float64
+
+	pages []*Page
+	page  *Page
+	y     float64 // the top of the space remaining on page
+}
+
+// Render adds pages of the given size to d, with the invoice on them.
+func (inv *Invoice) Render(d *Document, width, height float64) {
+	l := &invoiceLayout{
+		inv:    inv,
+		doc:    d,
+		width:  width,
+		height: height,
+		font:   inv.Font,
+		bold:   inv.BoldFont,
+		size:   inv.Size,
+	}
+	if l.bold == nil {
+		l.bold = l.font
+	}
+	if l.size == 0 {
+		l.size = 10
+	}
+	margin := inv.Margin
+	if margin == 0 {
+		margin = 54
+	}
+	l.leading = l.size * 1.4
+	l.left, l.right = margin, width-margin
+	l.top = height - margin
+	l.bottom = margin + 2*l.leading
+	// The number columns are narrowed in proportion on pages with less
+	// than 504 points (7 inches) between the margins, such as receipts.
+	scale := math.Min(1, (l.right-l.left)/504)
+	l.amount = l.right
+	l.unitX = l.amount - 90*scale
+	l.qtyX = l.unitX - 90*scale
+	l.descWidth = l.qtyX - 60*scale - l.left
+
+	l.newPage()
+	l.drawHeader()
+	l.drawAddresses()
+	if len(inv.Items) > 0 {
+		l.drawTableHeader()
+		for _, item := range inv.Items {
+			l.drawItem(item)
+		}
+	}
+	l.drawTotals()
+	l.drawNotes()
+	l.drawFooters()
+}
+
+func (l *invoiceLayout) newPage() {
+	l.page = l.doc.NewPage(l.width, l.height)
+	l.pages = append(l.pages, l.page)
+	l.y = l.top
+}
+
+// ensureSpace starts a new page if there is less than height left on the
+// current one, and reports whether it did.
+func (l *invoiceLayout) ensureSpace(height float64) bool {
+	if l.y-height >= l.bottom {
+		return false
+	}
+	l.newPage()
+	return true
+}
+
+// baseline returns the baseline for a line of text at the top of the
+// remaining space, and moves the top down past the line.
+func (l *invoiceLayout) baseline() float64 {
+	l.y -= l.leading
+	return l.y + (l.leading-l.size)/2 + l.size*0.2
+}
+
+func (l *invoiceLayout) drawHeader() {
+	p := l.page
+	top := l.y
+	logoBottom := top
+	if l.inv.Logo != nil {
+		const logoWidth, logoHeight = 160, 60
+		p.DrawImageFit(l.inv.Logo, l.left, top-logoHeight, logoWidth, logoHeight)
+		logoBottom = top - logoHeight
+	}
+
+	title := l.inv.Title
+	if title == "" {
+		title = "Invoice"
+	}
+	titleSize := l.size * 2
+	p.SetFont(l.bold, titleSize)
+	p.Right(l.right, top-titleSize*0.8, title)
+	l.y = top - titleSize*1.2
+
+	for _, detail := range l.inv.Details {
+		y := l.baseline()
+		p.SetFont(l.bold, l.size)
+		p.Right(l.unitX, y, detail[0])
+		p.SetFont(l.font, l.size)
+		p.Right(l.right, y, detail[1])
+	}
+	if logoBottom < l.y {
+		l.y = logoBottom
+	}
+	l.y -= l.leading
+}
+
+func (l *invoiceLayout) drawAddresses() {
+	if l.inv.From == "" && l.inv.To == "" {
+		return
+	}
+	p := l.page
+	top := l.y
+	lowest := top
+	block := func(x float64, label, address string) {
+		if address == "" {
+			return
+		}
+		l.y = top
+		p.SetFont(l.bold, l.size)
+		p.Left(x, l.baseline(), label)
+		p.SetFont(l.font, l.size)
+		for _, line := range strings.Split(address, "\n") {
+			p.Left(x, l.baseline(), line)
+		}
+		if l.y < lowest {
+			lowest = l.y
+		}
+	}
+	block(l.left, "From", l.inv.From)
+	block(l.left+(l.right-l.left)/2, "Bill To", l.inv.To)
+	l.y = lowest - l.leading
+}
+
+// drawTableHeader draws the column headings of the items table.
+func (l *invoiceLayout) drawTableHeader() {
+	p := l.page
+	y := l.baseline()
+	p.SetFont(l.bold, l.size)
+	p.Left(l.left, y, "Description")
+	p.Right(l.qtyX, y, "Qty")
+	p.Right(l.unitX, y, "Unit Price")
+	p.Right(l.amount, y, "Amount")
+	l.y -= 2
+	p.SetLineWidth(0.75)
+	p.MoveTo(l.left, l.y)
+	p.LineTo(l.right, l.y)
+	p.Stroke()
+	l.y -= 2
+}
+
+func (l *invoiceLayout) drawItem(item InvoiceItem) {
+	lines := l.font.WrapLines(item.Description, l.descWidth, l.size)
+	if len(lines) == 0 {
+		lines = []WrappedLine{{}}
+	}
+	height := float64(len(lines))*l.leading + 4
+	if l.ensureSpace(height) {
+		l.drawTableHeader()
+	}
+
+	p := l.page
+	p.SetFont(l.font, l.size)
+	top := l.y
+	for i, line := range lines {
+		y := l.baseline()
+		p.Left(l.left, y, line.Text)
+		if i == 0 {
+			p.Right(l.qtyX, y, strconv.FormatFloat(item.Quantity, 'f', -1, 64))
+			p.Right(l.unitX, y, l.inv.formatAmount(item.UnitPrice))
+			p.Right(l.amount, y, l.inv.formatAmount(item.Amount()))
+		}
+	}
+	l.y = top - height
+	p.Save()
+	p.SetLineWidth(0.25)
+	p.StrokeGray(0.75)
+	p.MoveTo(l.left, l.y+2)
+	p.LineTo(l.right, l.y+2)
+	p.Stroke()
+	p.Restore()
+}
+
+func (l *invoiceLayout) drawTotals() {
+	type row struct {
+		label, value string
+	}
+	rows := []row{{"Subtotal", l.inv.formatAmount(l.inv.Subtotal())}}
+	if l.inv.TaxRate != 0 {
+		label := l.inv.TaxLabel
+		if label == "" {
+			label = "Tax"
+		}
+		rate := strconv.FormatFloat(l.inv.TaxRate*100, 'f', -1, 64)
+		rows = append(rows, row{fmt.Sprintf("%s (%s%%)", label, rate), l.inv.formatAmount(l.inv.Tax())})
+	}
+
+	l.y -= l.leading / 2
+	l.ensureSpace(float64(len(rows)+1)*l.leading + 4)
+	p := l.page
+	p.SetFont(l.font, l.size)
+	for _, r := range rows {
+		y := l.baseline()
+		p.Right(l.unitX, y, r.label)
+		p.Right(l.amount, y, r.value)
+	}
+	l.y -= 2
+	p.SetLineWidth(0.75)
+	p.MoveTo(l.qtyX, l.y)
+	p.LineTo(l.right, l.y)
+	p.Stroke()
+	l.y -= 2
+	y := l.baseline()
+	p.SetFont(l.bold, l.size)
+	p.Right(l.unitX, y, "Total")
+	p.Right(l.amount, y, l.inv.formatAmount(l.inv.Total()))
+	l.y -= l.leading
+}
+
+func (l *invoiceLayout) drawNotes() {
+	if l.inv.Notes == "" {
+		return
+	}
+	for _, para := range strings.Split(l.inv.Notes, "\n") {
+		for _, line := range l.font.WrapLines(para, l.right-l.left, l.size) {
+			l.ensureSpace(l.leading)
+			l.page.SetFont(l.font, l.size)
+			l.page.Left(l.left, l.baseline(), line.Text)
+		}
+	}
+}
+
+// drawFooters draws the footer and page numbers at the bottom of each page.
+func (l *invoiceLayout) drawFooters() {
+	size := l.size * 0.8
+	y := l.bottom - 2*l.leading
+	for i, p := range l.pages {
+		p.SetFont(l.font, size)
+		if l.inv.Footer != "" {
+			p.Center(l.width/2, y, l.inv.Footer)
+		}
+		if len(l.pages) > 1 {
+			p.Right(l.right, y, fmt.Sprintf("Page %d of %d", i+1, len(l.pages)))
+		}
+	}
+}
@@ -0,0 +1,49 @@
+package pdf
+
+import (
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestInvoiceSubtotalMatchesLines(t *testing.T) {
+	inv := &Invoice{Items: []InvoiceItem{
+		{"a", 1, 0.014},
+		{"b", 1, 0.014},
+		{"c", 1, 0.014},
+	}}
+	// Each line is printed as 0.01.
+	if got := inv.Subtotal(); got != 0.03 {
+		t.Errorf("Subtotal() = %g, want 0.03", got)
+	}
+}
+
+func TestInvoiceNarrowPage(t *testing.T) {
+	d := new(Document)
+	inv := &Invoice{
+		Font:    testFont(t),
+		Details: [][2]string{{"Receipt #", "1234"}},
+		Items: []InvoiceItem{
+			{"Coffee", 2, 3.5},
+			{"A pastry with a long description", 1, 4.25},
+		},
+	}
+	inv.Render(d, 226, 400)
+
+	text, err := d.ExtractText()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !strings.Contains(text, "Qty") {
+		t.Errorf("Qty column missing:\n%s", text)
+	}
+
+	tokens := pdfTokens(d.Page(0).pageContent())
+	for i, tok := range tokens {
+		if tok == "Td" && i >= 2 {
+			if x, _ := strconv.ParseFloat(tokens[i-2], 64); x < 0 || x > 226 {
+				t.Errorf("text at x = %g, off the 226-point page", x)
+			}
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package pdf
+
+import "fmt"
+
+// A LayerUsage specifies when a Layer is visible.
+type LayerUsage int
+
+const (
+	// LayerAlways is visible both on screen and when printed.
+	LayerAlways LayerUsage = iota
+
+	// LayerPrintOnly is hidden on screen, but printed. It is for things
+	// like crop marks and color bars.
+	LayerPrintOnly
+
+	// LayerScreenOnly is visible on screen, but not printed. It is for
+	// things like non-printing guides and internal notes.
+	LayerScreenOnly
+)
+
+// A Layer is an optional content group: a set of content that can be shown
+// or hidden as a unit, and that appears in the viewer's list of layers.
+type Layer struct {
+	name  string
+	usage LayerUsage
+}
+
+// NewLayer adds a layer to the document, with the given name and
+// visibility. Content is added to the layer by drawing it between
+// Page.BeginLayer and Page.EndLayer.
+//
+// Print-only layers are off in the default configuration, and turned on by
+// the /Print usage application, so that viewers that don't apply usage
+// settings still hide them on screen.
+func (d *Document) NewLayer(name string, usage LayerUsage) *Layer {
+	l := &Layer{name: name, usage: usage}
+	d.layers = append(d.layers, l)
+	return l
+}
+
+func (l *Layer) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Type /OCG /Name %s ", textString(l.name))
+	switch l.usage {
+	case LayerPrintOnly:
+		e.WriteString("/Usage << /View << /ViewState /OFF >> /Print << /PrintState /ON >> >> ")
+	case LayerScreenOnly:
+		e.WriteString("/Usage << /View << /ViewState /ON >> /Print << /PrintState /OFF >> >> ")
+	}
+	e.WriteString(">>")
+}
+
+// BeginLayer marks the start of content that belongs to l. Each call to
+// BeginLayer must be matched by a call to EndLayer.
+func (p *Page) BeginLayer(l *Layer) {
+	id, ok := p.layers[l]
+	if !ok {
+		if p.layers == nil {
+			p.layers = make(map[*Layer]int)
+		}
+		id = len(p.layers)
+		p.layers[l] = id
+	}
+	p.contents.name("OC")
+	p.contents.name(fmt.Sprintf("OC%d", id))
+	p.contents.op("BDC")
+	p.markDepth++
+}
+
+// EndLayer marks the end of content started with BeginLayer.
+func (p *Page) EndLayer() {
+	p.contents.op("EMC")
+	p.markDepth--
+	if p.markDepth < 0 {
+		p.unbalanced = true
+	}
+}
+
+// writeLayers writes the catalog's /OCProperties entry, if the document has
+// any layers.
+func (d *Document) writeLayers(e *encoder) {
+	if len(d.layers) == 0 {
+		return
+	}
+	var all, off, usage []string
+	for _, l := range d.layers {
+		ref := fmt.Sprintf("%d 0 R", e.getRef(l))
+		all = append(all, ref)
+		if l.usage == LayerPrintOnly {
+			off = append(off, ref)
+		}
+		if l.usage != LayerAlways {
+			usage = append(usage, ref)
+		}
+	}
+	fmt.Fprintf(e, "/OCProperties << /OCGs %s /D << /Order %s ", all, all)
+	if len(off) > 0 {
+		fmt.Fprintf(e, "/OFF %s ", off)
+	}
+	if len(usage) > 0 {
+		fmt.Fprintf(e, "/AS [<< /Event /View /OCGs %s /Category [/View] >> << /Event /Print /OCGs %s /Category [/Print] >>] ", usage, usage)
+	}
+	e.WriteString(">> >> ")
+}
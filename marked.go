@@ -0,0 +1,95 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// A Name is a PDF name object, for property values in MarkedContentPoint.
+// (Plain strings are written as PDF strings.)
+type Name string
+
+// BeginMarkedContent marks the start of a marked-content sequence with the
+// given tag (BMC), so that tools reading the content stream can identify a
+// region of the page, such as a chart or a table cell. Each call must be
+// matched by a call to EndMarkedContent. Marked content that isn't part of
+// the document's structure doesn't affect how the page is displayed.
+func (p *Page) BeginMarkedContent(tag string) {
+	p.contents.b.WriteString(formatName(tag))
+	p.contents.b.WriteByte(' ')
+	p.contents.op("BMC")
+	p.markDepth++
+}
+
+// EndMarkedContent marks the end of a sequence started with
+// BeginMarkedContent.
+func (p *Page) EndMarkedContent() {
+	p.contents.op("EMC")
+	p.markDepth--
+	if p.markDepth < 0 {
+		p.unbalanced = true
+	}
+}
+
+// MarkedContentPoint marks a single point in the content stream with the
+// given tag, and optionally a property list (DP, or MP if properties is
+// empty). Property values may be strings, Names, numbers, bools, nil,
+// slices of values ([]interface{}), or nested property lists
+// (map[string]interface{}).
+func (p *Page) MarkedContentPoint(tag string, properties map[string]interface{}) {
+	p.contents.b.WriteString(formatName(tag))
+	p.contents.b.WriteByte(' ')
+	if len(properties) == 0 {
+		p.contents.op("MP")
+		return
+	}
+	p.contents.b.WriteString(p.formatValue(properties))
+	p.contents.b.WriteByte(' ')
+	p.contents.op("DP")
+}
+
+// formatValue formats v as a PDF object, for a property list. Values of
+// unsupported types are written as null, and reported by Validate.
+func (p *Page) formatValue(v interface{}) string {
+	switch v := v.(type) {
+	case nil:
+		return "null"
+	case string:
+		return textString(v)
+	case Name:
+		return formatName(string(v))
+	case bool:
+		return fmt.Sprint(v)
+	case int:
+		return fmt.Sprint(v)
+	case int64:
+		return fmt.Sprint(v)
+	case float64:
+		return formatNum(v)
+	case float32:
+		return formatNum(float64(v))
+	case []interface{}:
+		items := make([]string, len(v))
+		for i, item := range v {
+			items[i] = p.formatValue(item)
+		}
+		return "[" + strings.Join(items, " ") + "]"
+	case map[string]interface{}:
+		keys := make([]string, 0, len(v))
+		for k := range v {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		var b strings.Builder
+		b.WriteString("<<")
+		for _, k := range keys {
+			fmt.Fprintf(&b, " %s %s", formatName(k), p.formatValue(v[k]))
+		}
+		b.WriteString(" >>")
+		return b.String()
+	default:
+		p.problems = append(p.problems, fmt.Sprintf("unsupported property value type %T", v))
+		return "null"
+	}
+}
@@ -0,0 +1,81 @@
+package pdf
+
+// Sizes of printers' marks, in points.
+const (
+	markLength    = 18
+	markLineWidth = 0.25
+	targetRadius  = 6
+)
+
+// trimBox returns the page's trim box, or its media box if no trim box has
+// been set.
+func (p *Page) trimBox() Rect {
+	if r, ok := p.boxes["TrimBox"]; ok {
+		return r
+	}
+	return p.MediaBox()
+}
+
+// AddCropMarks draws crop marks at the corners of the page's trim box (see
+// SetTrimBox), in the Registration color. The marks are offset from the
+// corners by bleed, so that they stay outside the area that artwork bleeds
+// into. If no bleed box has been set, it is set to the trim box extended by
+// bleed. The media box needs to be large enough to leave room for the marks
+// outside the trim box.
+func (p *Page) AddCropMarks(bleed float64) {
+	trim := p.trimBox()
+	if _, ok := p.boxes["BleedBox"]; !ok {
+		p.SetBleedBox(trim.Inset(-bleed))
+	}
+
+	p.Save()
+	p.SetStrokeColor(Registration)
+	p.SetLineWidth(markLineWidth)
+	for _, x := range [2]float64{trim.X0, trim.X1} {
+		for _, y := range [2]float64{trim.Y0, trim.Y1} {
+			// dx and dy point away from the trim box.
+			dx, dy := 1.0, 1.0
+			if x == trim.X0 {
+				dx = -1
+			}
+			if y == trim.Y0 {
+				dy = -1
+			}
+			p.MoveTo(x+dx*bleed, y)
+			p.LineTo(x+dx*(bleed+markLength), y)
+			p.MoveTo(x, y+dy*bleed)
+			p.LineTo(x, y+dy*(bleed+markLength))
+		}
+	}
+	p.Stroke()
+	p.Restore()
+}
+
+// AddRegistrationMarks draws a registration target (a circle with
+// crosshairs) centered outside each edge of the page's bleed box (or its
+// trim box, if no bleed box has been set), in the Registration color.
+func (p *Page) AddRegistrationMarks() {
+	box, ok := p.boxes["BleedBox"]
+	if !ok {
+		box = p.trimBox()
+	}
+	const offset = markLength/2 + 3
+	midX, midY := (box.X0+box.X1)/2, (box.Y0+box.Y1)/2
+	centers := [4][2]float64{
+		{midX, box.Y1 + offset},
+		{box.X1 + offset, midY},
+		{midX, box.Y0 - offset},
+		{box.X0 - offset, midY},
+	}
+
+	p.Save()
+	p.SetStrokeColor(Registration)
+	p.SetLineWidth(markLineWidth)
+	for _, c := range centers {
+		x, y := c[0], c[1]
+		p.circle(x, y, targetRadius)
+		p.HLine(x-markLength/2, x+markLength/2, y)
+		p.VLine(x, y-markLength/2, y+markLength/2)
+	}
+	p.Restore()
+}
@@ -0,0 +1,156 @@
+package pdf
+
+import (
+	"strconv"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// SetOutlines sets whether text in f is drawn as filled glyph outlines in
+// the page's content stream, instead of with the font's glyphs. This is for
+// fonts whose licenses don't allow embedding, or workflows that require
+// documents without embedded fonts: the font dictionary is still written,
+// so that the text can be searched and copied, but its glyphs are empty,
+// and the text is shown in invisible mode (3 Tr) under the outlines.
+//
+// Outlines are drawn in the fill color that is current at the end of the
+// text object, so the fill color shouldn't be changed between BeginText and
+// EndText. Text rendered as outlines can also be used with ClipText.
+func (f *Font) SetOutlines(on bool) {
+	f.outlines = on
+}
+
+// identityMatrix is the initial value of the text matrix.
+var identityMatrix = [6]float64{1, 0, 0, 1, 0, 0}
+
+// moveTextLine moves the text line matrix (and the text matrix) by
+// (dx, dy), as the Td operator does.
+func (p *Page) moveTextLine(dx, dy float64) {
+	m := &p.tlm
+	m[4] += dx*m[0] + dy*m[2]
+	m[5] += dx*m[1] + dy*m[3]
+	p.tm = p.tlm
+}
+
+// advanceText advances the text matrix past the glyphs in tj (formatted
+// for the TJ operator), in the current font and size. If glyph is not nil,
+// it is called for each character code, before the advance past it.
+func (p *Page) advanceText(tj []string, glyph func(c byte)) {
+	f := p.currentFont
+	size := p.currentSize
+	m := &p.tm
+	var buffer sfnt.Buffer
+	for _, item := range tj {
+		if item == "" || item[0] != '(' {
+			n, err := strconv.ParseFloat(item, 64)
+			if err == nil {
+				m[4] -= n / 1000 * size * m[0]
+				m[5] -= n / 1000 * size * m[1]
+			}
+			continue
+		}
+		for _, c := range []byte(unquoteString(item)) {
+			if glyph != nil {
+				glyph(c)
+			}
+			advance := p.charSpacing + float64(f.codeAdvance(&buffer, c))/1000*size
+			m[4] += advance * m[0]
+			m[5] += advance * m[1]
+		}
+	}
+}
+
+// addOutlines adds the outlines of the glyphs in tj (formatted for the TJ
+// operator) to p.outlineText, starting at the current text position, and
+// advances the text matrix past them.
+func (p *Page) addOutlines(tj []string) {
+	f := p.currentFont
+	size := p.currentSize
+	if p.outlineText == nil {
+		p.outlineText = new(stream)
+	}
+	s := p.outlineText
+	m := &p.tm
+	point := func(pt [2]float64) []float64 {
+		return []float64{
+			pt[0]*m[0] + pt[1]*m[2] + m[4],
+			pt[0]*m[1] + pt[1]*m[3] + m[5],
+		}
+	}
+
+	p.advanceText(tj, func(c byte) {
+		path, _ := f.GlyphPath(f.toUnicode[c], size)
+		for _, seg := range path {
+			switch seg.Op {
+			case PathMoveTo:
+				s.num(point(seg.Points[0])...)
+				s.op("m")
+			case PathLineTo:
+				s.num(point(seg.Points[0])...)
+				s.op("l")
+			case PathCurveTo:
+				s.num(point(seg.Points[0])...)
+				s.num(point(seg.Points[1])...)
+				s.num(point(seg.Points[2])...)
+				s.op("c")
+			case PathClose:
+				s.op("h")
+			}
+		}
+	})
+}
+
+// flushOutlines paints the glyph outlines collected during a text object,
+// after the text object has ended: they are filled, or used as the clipping
+// path if the text was drawn by ClipText.
+func (p *Page) flushOutlines() {
+	if p.outlineText == nil {
+		return
+	}
+	p.contents.b.Write(p.outlineText.b.Bytes())
+	p.outlineText = nil
+	if p.clipText {
+		p.contents.op("W")
+		p.contents.op("n")
+	} else {
+		p.contents.op("f")
+	}
+}
+
+// codeAdvance returns the advance width (in 1/1000 em) of the glyph for
+// character code c, as given in the font dictionary's widths.
+func (f *Font) codeAdvance(buffer *sfnt.Buffer, c byte) int {
+	g, err := f.sfnt.GlyphIndex(buffer, f.toUnicode[c])
+	if err != nil {
+		return 0
+	}
+	w, err := f.advance(buffer, g)
+	if err != nil {
+		return 0
+	}
+	return w
+}
+
+// unquoteString reverses quoteString.
+func unquoteString(s string) string {
+	s = s[1 : len(s)-1]
+	b := make([]byte, 0, len(s))
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		if c == '\\' && i+1 < len(s) {
+			i++
+			switch s[i] {
+			case 'n':
+				c = '\n'
+			case 'r':
+				c = '\r'
+			case 't':
+				c = '\t'
+			default:
+				c = s[i]
+			}
+		}
+		b = append(b, c)
+	}
+	return string(b)
+}
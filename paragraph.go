@@ -0,0 +1,248 @@
+package pdf
+
+import (
+	"math"
+	"strings"
+)
+
+// A ParaStyle controls how a paragraph is broken across pages.
+type ParaStyle struct {
+	// MinOrphanLines is the minimum number of lines of a paragraph that
+	// may be left by themselves at the bottom of a page. If fewer lines
+	// would fit, the whole paragraph is moved to the next page.
+	MinOrphanLines int
+
+	// MinWidowLines is the minimum number of lines of a paragraph that
+	// may be carried over by themselves to the top of the next page. If
+	// fewer lines would be carried over, more lines are moved to the next
+	// page with them.
+	MinWidowLines int
+
+	// KeepWithNext is the number of lines of the following paragraph that
+	// must fit on the same page as a heading drawn with Heading.
+	KeepWithNext int
+
+	// LineBreaking selects how the paragraph is broken into lines.
+	LineBreaking LineBreaking
+}
+
+// A LineBreaking is a method of choosing where to break the lines of a
+// paragraph.
+type LineBreaking int
+
+const (
+	// GreedyBreaking puts as many words as will fit on each line before
+	// going on to the next one, like WordWrap.
+	GreedyBreaking LineBreaking = iota
+
+	// OptimalBreaking chooses the line breaks for the whole paragraph at
+	// once, in the style of the Knuth-Plass algorithm, to make the amount
+	// of unused space at the ends of the lines as even as possible. It is
+	// slower than GreedyBreaking, but it looks better, especially for
+	// justified text.
+	OptimalBreaking
+)
+
+// breakLines splits s into lines no wider than maxWidth (in 1/1000 em),
+// using the method specified by lb.
+func (f *Font) breakLines(s string, maxWidth int, lb LineBreaking) []wrappedLine {
+	if lb == OptimalBreaking {
+		return f.optimalLines(s, maxWidth)
+	}
+	return f.wrapLines(s, maxWidth)
+}
+
+// optimalLines splits s into lines, like wrapLines, but it chooses the set
+// of breaks that minimizes the total demerits of the paragraph's lines,
+// rather than filling each line as full as possible.
+//
+// Each line's badness is based on how much its interword spaces would need
+// to stretch to make it fill maxWidth, relative to a stretchability of half
+// the natural width of a space. Lines may not be shrunk, and the last line
+// is allowed to be short without penalty.
+func (f *Font) optimalLines(s string, maxWidth int) []wrappedLine {
+	words := strings.Fields(s)
+	if len(words) == 0 {
+		return nil
+	}
+
+	// first[i] is the width of words[i] at the start of a line;
+	// following[i] is its width after another word, including the space.
+	first := make([]int, len(words))
+	following := make([]int, len(words))
+	for i, w := range words {
+		_, first[i] = f.encodeAndKern(w, 0)
+		_, following[i] = f.encodeAndKern(" "+w, 0)
+	}
+	_, spaceWidth := f.encodeAndKern(" ", 0)
+	stretch := float64(spaceWidth) / 2
+	if stretch <= 0 {
+		stretch = 1
+	}
+
+	// best[j] is the lowest total demerits for setting words[:j], and
+	// breaks[j] is the start of the last line in that solution.
+	best := make([]float64, len(words)+1)
+	breaks := make([]int, len(words)+1)
+	for j := 1; j <= len(words); j++ {
+		best[j] = math.Inf(1)
+		width := 0
+		for i := j - 1; i >= 0; i-- {
+			// The line is words[i:j].
+			if i == j-1 {
+				width = first[i]
+			} else {
+				width += following[i+1] - first[i+1] + first[i]
+			}
+			if width > maxWidth && i < j-1 {
+				break
+			}
+
+			var badness float64
+			switch {
+			case j == len(words):
+				// The last line doesn't need to be full.
+			case width > maxWidth:
+				// A single word that is too long to fit.
+				badness = 1e6
+			default:
+				// A line with a single word is treated as if it had one
+				// space to stretch.
+				spaces := math.Max(float64(j-1-i), 1)
+				r := float64(maxWidth-width) / (stretch * spaces)
+				badness = 100 * r * r * r
+			}
+			demerits := (1 + badness) * (1 + badness)
+			if total := best[i] + demerits; total < best[j] {
+				best[j] = total
+				breaks[j] = i
+			}
+		}
+	}
+
+	var starts []int
+	for j := len(words); j > 0; j = breaks[j] {
+		starts = append(starts, breaks[j])
+	}
+
+	lines := make([]wrappedLine, 0, len(starts))
+	for k := len(starts) - 1; k >= 0; k-- {
+		start, end := starts[k], len(words)
+		if k > 0 {
+			end = starts[k-1]
+		}
+		line, lineWidth := f.encodeAndKern(words[start], 0)
+		for _, w := range words[start+1 : end] {
+			word, wordWidth := f.encodeAndKern(" "+w, 0)
+			line = append(line, word...)
+			lineWidth += wordWidth
+		}
+		lines = append(lines, wrappedLine{
+			words: words[start:end],
+			tj:    line,
+			width: lineWidth,
+		})
+	}
+	return lines
+}
+
+// linesToDraw returns how many of a paragraph's total lines should be drawn
+// on the current page, if there is room for available lines.
+func (style ParaStyle) linesToDraw(total, available int) int {
+	if available >= total {
+		return total
+	}
+	n := available
+	if total-n < style.MinWidowLines {
+		n = total - style.MinWidowLines
+	}
+	if n < style.MinOrphanLines || n < 0 {
+		n = 0
+	}
+	return n
+}
+
+// Paragraph displays s as a word-wrapped paragraph like WordWrap, with the
+// first baseline at y, and each following line lower by the leading (set
+// with SetLeading). Lines are drawn only as long as their baselines are no
+// lower than bottom. If the whole paragraph doesn't fit, it is broken
+// according to style, and the text that was not drawn is returned as rest, to
+// be continued on the next page. The returned y is the baseline for the line
+// following the last one drawn.
+func (p *Page) Paragraph(x, y, width, bottom float64, s string, style ParaStyle) (rest string, nextY float64) {
+	return p.paragraph(x, y, width, bottom, s, style, "P", AlignLeft)
+}
+
+// paragraph implements Paragraph, marking the text with tag if automatic
+// tagging is enabled, and positioning each line within width according to
+// align.
+func (p *Page) paragraph(x, y, width, bottom float64, s string, style ParaStyle, tag string, align Alignment) (rest string, nextY float64) {
+	lines := p.font().breakLines(s, int(width/p.currentSize*1000), style.LineBreaking)
+	available := 0
+	if y >= bottom {
+		available = len(lines)
+		if p.leading > 0 {
+			available = int((y-bottom)/p.leading) + 1
+		}
+	}
+	n := style.linesToDraw(len(lines), available)
+
+	if n > 0 {
+		p.beginTag(tag)
+		p.beginText()
+		lineX := x
+		for i, line := range lines[:n] {
+			newX := x
+			switch w := float64(line.width) * 0.001 * p.currentSize; align {
+			case AlignCenter:
+				newX = x + (width-w)/2
+			case AlignRight:
+				newX = x + width - w
+			}
+			if i == 0 {
+				p.TextMove(newX, y)
+			} else {
+				p.TextNextLine()
+				if newX != lineX {
+					p.TextMove(newX-lineX, 0)
+				}
+			}
+			lineX = newX
+			p.showTJ(line.tj)
+		}
+		p.endText()
+		p.endTag()
+	}
+
+	var remaining []string
+	for _, line := range lines[n:] {
+		remaining = append(remaining, line.words...)
+	}
+	return strings.Join(remaining, " "), y - float64(n)*p.leading
+}
+
+// Heading displays s like Paragraph, but as a block that is kept together and
+// kept with the paragraph that follows it: it is drawn only if all its lines
+// fit above bottom, along with style.KeepWithNext lines of the next paragraph
+// (whose line spacing is nextLeading). If they don't fit, nothing is drawn, ok
+// is false, and the heading should be moved to the next page along with its
+// paragraph.
+func (p *Page) Heading(x, y, width, bottom float64, s string, style ParaStyle, nextLeading float64) (nextY float64, ok bool) {
+	return p.heading(x, y, width, bottom, s, style, nextLeading, AlignLeft)
+}
+
+// heading implements Heading, positioning each line within width according
+// to align.
+func (p *Page) heading(x, y, width, bottom float64, s string, style ParaStyle, nextLeading float64, align Alignment) (nextY float64, ok bool) {
+	lines := p.font().breakLines(s, int(width/p.currentSize*1000), style.LineBreaking)
+	lastBaseline := y - float64(len(lines)-1)*p.leading
+	if style.KeepWithNext > 0 {
+		lastBaseline -= p.leading - nextLeading + float64(style.KeepWithNext)*nextLeading
+	}
+	if lastBaseline < bottom {
+		return y, false
+	}
+
+	_, nextY = p.paragraph(x, y, width, bottom, s, ParaStyle{LineBreaking: style.LineBreaking}, "H", align)
+	return nextY, true
+}
@@ -0,0 +1,91 @@
+package pdf
+
+import "fmt"
+
+// A Pattern is a tiling pattern: a cell that is repeated to fill an area.
+// The contents of the cell are drawn with the methods of the embedded Page.
+// A Pattern can be used on any page of the Document that created it.
+type Pattern struct {
+	*Page
+
+	// x and y are the origin of the pattern, in the default coordinate
+	// system of the page where it is used.
+	x, y float64
+}
+
+// NewTilingPattern returns a new Pattern with a cell of the given size. The
+// pattern's origin is at the origin of each page where it is used.
+func (d *Document) NewTilingPattern(width, height float64) *Pattern {
+	pat := &Pattern{
+		Page: &Page{
+			doc:    d,
+			width:  width,
+			height: height,
+			state:  initialState,
+		},
+	}
+	pat.NewFragment()
+	return pat
+}
+
+func (pat *Pattern) writeTo(e *encoder) {
+	// A pattern's cell has only one content stream, so any fragments are
+	// joined.
+	s := pat.contents
+	if len(pat.fragments) > 1 {
+		s = &stream{content: true}
+		for _, f := range pat.fragments {
+			endFragment(f)
+			s.b.Write(f.b.Bytes())
+		}
+	}
+	w, h := formatNum(pat.width), formatNum(pat.height)
+	s.extraData = fmt.Sprintf("/Type /Pattern /PatternType 1 /PaintType 1 /TilingType 1 /BBox [0 0 %s %s] /XStep %s /YStep %s /Matrix [1 0 0 1 %s %s] /Resources %s", w, h, w, h, formatNum(pat.x), formatNum(pat.y), pat.resources(e))
+	s.writeTo(e)
+}
+
+func (pat *Pattern) setColor(p *Page, stroke bool) {
+	patternID, ok := p.patterns[pat]
+	if !ok {
+		if p.patterns == nil {
+			p.patterns = make(map[*Pattern]int)
+		}
+		patternID = len(p.patterns)
+		p.patterns[pat] = patternID
+	}
+
+	p.contents.name("Pattern")
+	if stroke {
+		p.contents.op("CS")
+	} else {
+		p.contents.op("cs")
+	}
+	p.contents.name(fmt.Sprintf("P%d", patternID))
+	if stroke {
+		p.contents.op("SCN")
+	} else {
+		p.contents.op("scn")
+	}
+}
+
+// FillPattern sets pat as the paint to be used by Fill, so that filled areas
+// are covered with copies of the pattern cell. (A Pattern is also a Color,
+// so it can be used with SetFillColor and SetStrokeColor as well.)
+func (p *Page) FillPattern(pat *Pattern) {
+	p.SetFillColor(pat)
+}
+
+// DrawImageTiled fills the rectangle with its lower left corner at (x, y) and
+// the given width and height with copies of img, each scaled to tileWidth and
+// tileHeight. The tiles start at the lower left corner of the rectangle.
+func (p *Page) DrawImageTiled(img *Image, x, y, width, height, tileWidth, tileHeight float64) {
+	pat := p.doc.NewTilingPattern(tileWidth, tileHeight)
+	pat.x, pat.y = x, y
+	pat.DrawImage(img, 0, 0, tileWidth, tileHeight)
+
+	p.Save()
+	p.FillPattern(pat)
+	p.Rectangle(x, y, width, height)
+	p.Fill()
+	p.Restore()
+}
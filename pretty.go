@@ -0,0 +1,210 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+)
+
+// SetPretty turns on a debugging mode that makes the encoded PDF easier to
+// read: streams are not compressed, content streams are written with one
+// operator per line (indented inside q/Q, BT/ET, and marked-content
+// sequences), and dictionaries are written with one entry per line. The
+// output is larger, so it is not recommended for production use.
+func (d *Document) SetPretty(pretty bool) {
+	d.pretty = pretty
+}
+
+// pdfTokens splits data into PDF tokens. It doesn't need to handle all of
+// PDF syntax; only what this package generates.
+func pdfTokens(data []byte) []string {
+	var tokens []string
+	i := 0
+	for i < len(data) {
+		c := data[i]
+		start := i
+		switch {
+		case isPDFSpace(c):
+			i++
+			continue
+		case c == '(':
+			depth := 0
+			for ; i < len(data); i++ {
+				if data[i] == '\\' {
+					i++
+					continue
+				}
+				if data[i] == '(' {
+					depth++
+				} else if data[i] == ')' {
+					depth--
+					if depth == 0 {
+						i++
+						break
+					}
+				}
+			}
+		case c == '<' && i+1 < len(data) && data[i+1] == '<', c == '>' && i+1 < len(data) && data[i+1] == '>':
+			i += 2
+		case c == '<':
+			for i < len(data) && data[i] != '>' {
+				i++
+			}
+			i++
+		case c == '[' || c == ']' || c == '{' || c == '}':
+			i++
+		case c == '%':
+			for i < len(data) && data[i] != '\n' && data[i] != '\r' {
+				i++
+			}
+		default:
+			// A name, number, or operator.
+			i++
+			for i < len(data) && !isPDFSpace(data[i]) && !isPDFDelimiter(data[i]) {
+				i++
+			}
+		}
+		if i > len(data) {
+			i = len(data)
+		}
+		tokens = append(tokens, string(data[start:i]))
+	}
+	return tokens
+}
+
+func isPDFSpace(c byte) bool {
+	switch c {
+	case ' ', '\n', '\r', '\t', '\f', 0:
+		return true
+	}
+	return false
+}
+
+func isPDFDelimiter(c byte) bool {
+	return strings.IndexByte("()<>[]{}/%", c) != -1
+}
+
+// isOperator reports whether tok is a content-stream operator.
+func isOperator(tok string) bool {
+	switch tok {
+	case "true", "false", "null":
+		return false
+	}
+	c := tok[0]
+	return c >= 'a' && c <= 'z' || c >= 'A' && c <= 'Z' || c == '\'' || c == '"'
+}
+
+// joinTokens joins tokens with spaces, except just inside brackets.
+func joinTokens(tokens []string) string {
+	var b strings.Builder
+	for i, tok := range tokens {
+		if i > 0 && tokens[i-1] != "[" && tok != "]" {
+			b.WriteByte(' ')
+		}
+		b.WriteString(tok)
+	}
+	return b.String()
+}
+
+// prettyContent reformats a content stream with one operator per line.
+func prettyContent(data []byte) []byte {
+	var b bytes.Buffer
+	indent := 0
+	var operands []string
+	for _, tok := range pdfTokens(data) {
+		if !isOperator(tok) {
+			operands = append(operands, tok)
+			continue
+		}
+		switch tok {
+		case "Q", "ET", "EMC":
+			if indent > 0 {
+				indent--
+			}
+		}
+		b.WriteString(strings.Repeat("  ", indent))
+		b.WriteString(joinTokens(append(operands, tok)))
+		b.WriteByte('\n')
+		operands = operands[:0]
+		switch tok {
+		case "q", "BT", "BDC", "BMC":
+			indent++
+		}
+	}
+	if len(operands) > 0 {
+		b.WriteString(joinTokens(operands))
+		b.WriteByte('\n')
+	}
+	return b.Bytes()
+}
+
+// prettyObject reformats the part of an object before its stream data (if
+// any), writing dictionaries with one entry per line.
+func prettyObject(data []byte) []byte {
+	pp := &prettyPrinter{tokens: pdfTokens(data)}
+	for pp.pos < len(pp.tokens) {
+		if pp.pos > 0 {
+			pp.b.WriteByte(' ')
+		}
+		pp.value(0)
+	}
+	return pp.b.Bytes()
+}
+
+type prettyPrinter struct {
+	tokens []string
+	pos    int
+	b      bytes.Buffer
+}
+
+// value writes the next value (at the given indentation level).
+func (pp *prettyPrinter) value(indent int) {
+	tok := pp.tokens[pp.pos]
+	pp.pos++
+	switch tok {
+	case "<<":
+		pp.b.WriteString("<<\n")
+		for pp.pos < len(pp.tokens) && pp.tokens[pp.pos] != ">>" {
+			pp.b.WriteString(strings.Repeat("  ", indent+1))
+			pp.value(indent + 1)
+			if pp.pos < len(pp.tokens) && pp.tokens[pp.pos] != ">>" {
+				pp.b.WriteByte(' ')
+				pp.value(indent + 1)
+			}
+			pp.b.WriteByte('\n')
+		}
+		pp.pos++
+		pp.b.WriteString(strings.Repeat("  ", indent))
+		pp.b.WriteString(">>")
+	case "[":
+		pp.b.WriteByte('[')
+		first := true
+		for pp.pos < len(pp.tokens) && pp.tokens[pp.pos] != "]" {
+			if !first {
+				pp.b.WriteByte(' ')
+			}
+			first = false
+			pp.value(indent)
+		}
+		pp.pos++
+		pp.b.WriteByte(']')
+	default:
+		pp.b.WriteString(tok)
+		// An indirect reference (such as "12 0 R") is three tokens.
+		if pp.pos+1 < len(pp.tokens) && pp.tokens[pp.pos+1] == "R" && isInteger(tok) && isInteger(pp.tokens[pp.pos]) {
+			pp.b.WriteString(" " + pp.tokens[pp.pos] + " R")
+			pp.pos += 2
+		}
+	}
+}
+
+func isInteger(tok string) bool {
+	if tok == "" {
+		return false
+	}
+	for i := 0; i < len(tok); i++ {
+		if tok[i] < '0' || tok[i] > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,119 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strconv"
+)
+
+// A Ref is a reference to an object added with Document.AddRawObject or
+// Document.ReserveObject.
+type Ref struct {
+	obj *rawObject
+}
+
+// A rawObject is an object that was serialized outside the package.
+type rawObject struct {
+	body []byte
+	refs []Ref
+
+	// reserved is true for an object from ReserveObject that hasn't been
+	// defined yet.
+	reserved bool
+}
+
+func (o *rawObject) writeTo(e *encoder) {
+	if o.reserved {
+		e.WriteString("null")
+		return
+	}
+	body := o.body
+	for i, r := range o.refs {
+		ref := "null"
+		if r.obj != nil {
+			ref = fmt.Sprintf("%d 0 R", e.getRef(r.obj))
+		}
+		body = bytes.Replace(body, []byte("{{"+strconv.Itoa(i)+"}}"), []byte(ref), -1)
+	}
+	e.Write(body)
+}
+
+// AddRawObject adds an object that has already been serialized to the
+// document, and returns a reference to it. The body is written verbatim
+// between "N 0 obj" and "endobj", so it must be a complete PDF object (a
+// stream object includes its dictionary, with the correct /Length, and the
+// stream and endstream keywords).
+//
+// Since object numbers aren't assigned until the document is encoded, body
+// can't contain indirect references directly. Instead, it can refer to other
+// raw objects with the placeholders {{0}}, {{1}}, and so on, which are
+// replaced with references to refs[0], refs[1], etc.
+func (d *Document) AddRawObject(body []byte, refs ...Ref) Ref {
+	o := &rawObject{
+		body: append([]byte(nil), body...),
+		refs: append([]Ref(nil), refs...),
+	}
+	d.rawObjects = append(d.rawObjects, o)
+	return Ref{o}
+}
+
+// ReserveObject adds an object to the document whose body will be supplied
+// later with DefineObject, and returns a reference to it. The reference can
+// be used (in the refs of raw objects, or with RawResource) before the
+// object is defined, for content that isn't known until later, such as
+// something that depends on the final number of pages. If the object is
+// still undefined when the document is encoded, it is written as null, and
+// Validate reports it.
+func (d *Document) ReserveObject() Ref {
+	o := &rawObject{reserved: true}
+	d.rawObjects = append(d.rawObjects, o)
+	return Ref{o}
+}
+
+// DefineObject supplies the body of an object reserved with ReserveObject.
+// The body and refs are interpreted as they are by AddRawObject. Calling it
+// again replaces the earlier definition.
+func (d *Document) DefineObject(r Ref, body []byte, refs ...Ref) {
+	if r.obj == nil {
+		panic("pdf: DefineObject called with a zero Ref")
+	}
+	r.obj.body = append([]byte(nil), body...)
+	r.obj.refs = append([]Ref(nil), refs...)
+	r.obj.reserved = false
+}
+
+// RawResource adds the object r to the page's resources, in the given
+// category (such as "/XObject" or "/Shading"), and returns its resource
+// name (without the slash), for use with Raw.
+func (p *Page) RawResource(category string, r Ref) string {
+	if p.rawResources == nil {
+		p.rawResources = make(map[string][]Ref)
+	}
+	list := p.rawResources[category]
+	for i, ref := range list {
+		if ref == r {
+			return fmt.Sprintf("R%d", i)
+		}
+	}
+	p.rawResources[category] = append(list, r)
+	return fmt.Sprintf("R%d", len(list))
+}
+
+// Raw writes ops to the page's content stream verbatim. It is an escape
+// hatch for content the package doesn't support; the caller is responsible
+// for making it valid, and for balancing any q/Q or BT/ET pairs in it.
+func (p *Page) Raw(ops string) {
+	p.contents.b.WriteString(ops)
+	p.contents.b.WriteByte('\n')
+}
+
+// writeRawResources writes the resource dictionary entries for the raw
+// objects in category.
+func (p *Page) writeRawResources(b *bytes.Buffer, e *encoder, category string) {
+	for i, r := range p.rawResources[category] {
+		if r.obj == nil {
+			continue
+		}
+		fmt.Fprintf(b, "/R%d %d 0 R ", i, e.getRef(r.obj))
+	}
+}
@@ -0,0 +1,384 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// Redact removes the content under r from the page and covers r with an
+// opaque black box. Unlike drawing a box over the content, which leaves it
+// in the file to be copied or extracted, the content is actually taken out
+// of the page's content streams when the document is encoded (and when its
+// text is extracted): characters whose glyphs overlap r are replaced by
+// spacing that keeps the rest of the line in place, path segments and
+// images that lie entirely inside r are left out, and the box is drawn last,
+// on top of everything else.
+//
+// Links and stamps that lie entirely inside r are left out too. Images,
+// links, and stamps that are only partly inside r, and the contents of
+// templates, are not changed; they are hidden under the box (or, for links,
+// still work) and are still present in the file.
+// Embedded fonts still include the glyphs for redacted characters, though
+// not which characters were shown or where.
+func (p *Page) Redact(r Rect) {
+	if p.topDown {
+		r.Y0, r.Y1 = p.height-r.Y1, p.height-r.Y0
+	}
+	p.redactions = append(p.redactions, r)
+}
+
+// redactedContent returns content with the redactions applied, and the
+// boxes drawn over them.
+func (p *Page) redactedContent(content []byte) []byte {
+	r := &redactor{page: p, ctm: identityMatrix}
+	b := new(bytes.Buffer)
+	b.WriteString("q\n")
+	r.redact(content, b)
+	b.WriteString("Q\n0 g\n")
+	for _, box := range p.redactions {
+		fmt.Fprintf(b, "%s %s %s %s re f\n", formatNum(box.X0), formatNum(box.Y0), formatNum(box.Width()), formatNum(box.Height()))
+	}
+	return b.Bytes()
+}
+
+// A redactor rewrites a content stream without the content under a page's
+// redactions. Like textExtractor, it understands only the content that
+// this package produces.
+type redactor struct {
+	page *Page
+
+	ctm [6]float64
+	textState
+	stack   []redactorState
+	tm, tlm [6]float64
+
+	// path holds the subpaths of the path under construction, each as a
+	// list of operations, and bounds holds their bounding boxes in
+	// default user space.
+	path   [][][]string
+	bounds []Rect
+}
+
+// A redactorState is the part of the graphics state that a redactor saves
+// and restores with q and Q.
+type redactorState struct {
+	ctm [6]float64
+	textState
+}
+
+// multiply returns the matrix product a × b: the transformation that
+// applies a and then b.
+func multiply(a, b [6]float64) [6]float64 {
+	return [6]float64{
+		a[0]*b[0] + a[1]*b[2],
+		a[0]*b[1] + a[1]*b[3],
+		a[2]*b[0] + a[3]*b[2],
+		a[2]*b[1] + a[3]*b[3],
+		a[4]*b[0] + a[5]*b[2] + b[4],
+		a[4]*b[1] + a[5]*b[3] + b[5],
+	}
+}
+
+// transformBounds returns the bounding box of r transformed by m.
+func transformBounds(r Rect, m [6]float64) Rect {
+	var result Rect
+	for i, c := range [4][2]float64{{r.X0, r.Y0}, {r.X1, r.Y0}, {r.X0, r.Y1}, {r.X1, r.Y1}} {
+		x := c[0]*m[0] + c[1]*m[2] + m[4]
+		y := c[0]*m[1] + c[1]*m[3] + m[5]
+		if i == 0 {
+			result = Rect{x, y, x, y}
+		} else {
+			result = result.Union(Rect{x, y, x, y})
+		}
+	}
+	return result
+}
+
+// redacted reports whether b (in default user space) lies entirely inside
+// one of p's redactions.
+func (p *Page) redacted(b Rect) bool {
+	for _, box := range p.redactions {
+		if b.X0 >= box.X0 && b.X1 <= box.X1 && b.Y0 >= box.Y0 && b.Y1 <= box.Y1 {
+			return true
+		}
+	}
+	return false
+}
+
+// covered reports whether b lies entirely inside one of the redactions.
+func (r *redactor) covered(b Rect) bool {
+	return r.page.redacted(b)
+}
+
+// overlaps reports whether b overlaps one of the redactions.
+func (r *redactor) overlaps(b Rect) bool {
+	for _, box := range r.page.redactions {
+		if b.X0 < box.X1 && b.X1 > box.X0 && b.Y0 < box.Y1 && b.Y1 > box.Y0 {
+			return true
+		}
+	}
+	return false
+}
+
+// redact writes content to b, one operation per line, leaving out what is
+// under the redactions.
+func (r *redactor) redact(content []byte, b *bytes.Buffer) {
+	write := func(tokens []string) {
+		b.WriteString(joinTokens(tokens))
+		b.WriteByte('\n')
+	}
+
+	var operands []string
+	for _, tok := range pdfTokens(content) {
+		if !isOperator(tok) {
+			operands = append(operands, tok)
+			continue
+		}
+		nums := func() []float64 {
+			v := make([]float64, len(operands))
+			for i, s := range operands {
+				v[i], _ = strconv.ParseFloat(s, 64)
+			}
+			return v
+		}
+		op := append(operands, tok)
+
+		switch tok {
+		case "q":
+			r.stack = append(r.stack, redactorState{r.ctm, r.textState})
+		case "Q":
+			if n := len(r.stack); n > 0 {
+				r.ctm, r.textState = r.stack[n-1].ctm, r.stack[n-1].textState
+				r.stack = r.stack[:n-1]
+			}
+		case "cm":
+			if v := nums(); len(v) == 6 {
+				var m [6]float64
+				copy(m[:], v)
+				r.ctm = multiply(m, r.ctm)
+			}
+		case "BT":
+			r.tm, r.tlm = identityMatrix, identityMatrix
+		case "Tf":
+			if len(operands) == 2 {
+				r.font = nil
+				for f, id := range r.page.fonts {
+					if operands[0] == fmt.Sprintf("/F%d", id) {
+						r.font = f
+					}
+				}
+				r.size, _ = strconv.ParseFloat(operands[1], 64)
+			}
+		case "TL":
+			if v := nums(); len(v) == 1 {
+				r.leading = v[0]
+			}
+		case "Tc":
+			if v := nums(); len(v) == 1 {
+				r.charSpacing = v[0]
+			}
+		case "Tw":
+			if v := nums(); len(v) == 1 {
+				r.wordSpacing = v[0]
+			}
+		case "Tm":
+			if v := nums(); len(v) == 6 {
+				copy(r.tlm[:], v)
+				r.tm = r.tlm
+			}
+		case "Td", "TD":
+			if v := nums(); len(v) == 2 {
+				r.moveLine(v[0], v[1])
+				if tok == "TD" {
+					r.leading = -v[1]
+				}
+			}
+		case "T*":
+			r.moveLine(0, -r.leading)
+
+		case "Tj", "'", "\"":
+			if len(operands) == 0 {
+				break
+			}
+			if tok == "\"" && len(operands) == 3 {
+				v := nums()
+				r.wordSpacing, r.charSpacing = v[0], v[1]
+				write([]string{operands[0], "Tw"})
+				write([]string{operands[1], "Tc"})
+			}
+			if tok != "Tj" {
+				r.moveLine(0, -r.leading)
+				write([]string{"T*"})
+			}
+			write(r.show(operands[len(operands)-1:]))
+			operands = operands[:0]
+			continue
+		case "TJ":
+			if len(operands) >= 2 {
+				write(r.show(operands[1 : len(operands)-1]))
+				operands = operands[:0]
+				continue
+			}
+
+		case "m", "re":
+			r.path = append(r.path, nil)
+			r.bounds = append(r.bounds, Rect{math.Inf(1), math.Inf(1), math.Inf(-1), math.Inf(-1)})
+			fallthrough
+		case "l", "c", "v", "y", "h":
+			n := len(r.path) - 1
+			if n < 0 {
+				break
+			}
+			r.path[n] = append(r.path[n], append([]string(nil), op...))
+			v := nums()
+			if tok == "re" && len(v) == 4 {
+				v = []float64{v[0], v[1], v[0] + v[2], v[1] + v[3]}
+			}
+			for i := 0; i+1 < len(v); i += 2 {
+				r.bounds[n] = r.bounds[n].Union(transformBounds(Rect{v[i], v[i+1], v[i], v[i+1]}, r.ctm))
+			}
+			operands = operands[:0]
+			continue
+		case "W", "W*":
+			// The clipping path is ended by the n that follows. If all of
+			// it is covered, it is replaced by an empty rectangle, so that
+			// it still clips everything.
+			if !r.paintPath(b) {
+				write([]string{"0", "0", "0", "0", "re"})
+			}
+		case "S", "s", "f", "F", "f*", "B", "B*", "b", "b*", "n":
+			if r.paintPath(b) {
+				break
+			}
+			operands = operands[:0]
+			continue
+
+		case "Do":
+			if len(operands) == 1 && r.coveredXObject(operands[0]) {
+				operands = operands[:0]
+				continue
+			}
+		}
+		write(op)
+		operands = operands[:0]
+	}
+}
+
+// moveLine moves to the start of a new line, offset by (dx, dy) from the
+// start of the current one.
+func (r *redactor) moveLine(dx, dy float64) {
+	m := &r.tlm
+	m[4] += dx*m[0] + dy*m[2]
+	m[5] += dx*m[1] + dy*m[3]
+	r.tm = r.tlm
+}
+
+// show advances the text matrix past the text shown by the operands of TJ
+// (or Tj), and returns a TJ operation for it with the characters that
+// overlap a redaction replaced by spacing of the same width.
+func (r *redactor) show(items []string) []string {
+	result := []string{"["}
+	var kept []byte
+	var gap float64
+	flush := func() {
+		if len(kept) > 0 {
+			result = append(result, quoteString(string(kept)))
+			kept = kept[:0]
+		}
+		if gap != 0 {
+			result = append(result, formatNum(-gap))
+			gap = 0
+		}
+	}
+
+	var ascent, descent float64
+	if r.font != nil {
+		a, d := r.font.metrics()
+		ascent, descent = float64(a)/1000*r.size, float64(d)/1000*r.size
+	}
+	m := &r.tm
+	var buffer sfnt.Buffer
+	for _, item := range items {
+		if item[0] != '(' {
+			n, err := strconv.ParseFloat(item, 64)
+			if err != nil {
+				continue
+			}
+			if len(kept) > 0 {
+				flush()
+			}
+			gap -= n
+			m[4] -= n / 1000 * r.size * m[0]
+			m[5] -= n / 1000 * r.size * m[1]
+			continue
+		}
+		for _, c := range []byte(unquoteString(item)) {
+			advance := r.charSpacing
+			if r.font != nil {
+				advance += float64(r.font.codeAdvance(&buffer, c)) / 1000 * r.size
+			}
+			if c == ' ' {
+				advance += r.wordSpacing
+			}
+			glyph := transformBounds(Rect{0, -descent, advance, ascent}, multiply(*m, r.ctm))
+			if r.overlaps(glyph) && r.size != 0 {
+				if len(kept) > 0 {
+					flush()
+				}
+				gap += advance / r.size * 1000
+			} else {
+				if gap != 0 {
+					flush()
+				}
+				kept = append(kept, c)
+			}
+			m[4] += advance * m[0]
+			m[5] += advance * m[1]
+		}
+	}
+	flush()
+	return append(result, "]", "TJ")
+}
+
+// paintPath writes the subpaths of the current path that aren't covered by
+// a redaction, and clears the path. It reports false if there was a path
+// but all of it was left out.
+func (r *redactor) paintPath(b *bytes.Buffer) bool {
+	painted := false
+	for i, subpath := range r.path {
+		if r.covered(r.bounds[i]) {
+			continue
+		}
+		for _, op := range subpath {
+			b.WriteString(joinTokens(op))
+			b.WriteByte('\n')
+		}
+		painted = true
+	}
+	hadPath := len(r.path) > 0
+	r.path, r.bounds = nil, nil
+	if !painted && hadPath {
+		return false
+	}
+	return true
+}
+
+// coveredXObject reports whether the image or template with the resource
+// name name lies entirely inside a redaction.
+func (r *redactor) coveredXObject(name string) bool {
+	for _, id := range r.page.images {
+		if name == fmt.Sprintf("/Im%d", id) {
+			return r.covered(transformBounds(Rect{0, 0, 1, 1}, r.ctm))
+		}
+	}
+	for t, id := range r.page.templates {
+		if name == fmt.Sprintf("/Tp%d", id) {
+			return r.covered(transformBounds(Rect{0, 0, t.width, t.height}, r.ctm))
+		}
+	}
+	return false
+}
@@ -0,0 +1,50 @@
+package pdf
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+)
+
+func TestRedactAnnotations(t *testing.T) {
+	d := new(Document)
+	d.SetCompression(CompressNone)
+	p := d.NewPage(612, 792)
+	target := d.NewPage(612, 792)
+	stamp := d.NewStamp("Confidential", 80, 30)
+
+	p.LinkTo(Rect{100, 100, 200, 120}, Destination{Page: target, Mode: Fit})
+	p.LinkTo(Rect{100, 300, 400, 320}, Destination{Page: target, Mode: Fit})
+	p.PlaceStamp(stamp, 100, 500)
+	p.PlaceStamp(stamp, 400, 500)
+	p.Redact(Rect{90, 90, 300, 600})
+
+	out := d.Encode()
+	if n := bytes.Count(out, []byte("/Subtype /Link")); n != 1 {
+		t.Errorf("got %d links, want 1 (the one only partly under the redaction)", n)
+	}
+	if n := bytes.Count(out, []byte("/Subtype /Stamp")); n != 1 {
+		t.Errorf("got %d stamps, want 1 (the one outside the redaction)", n)
+	}
+}
+
+func TestRedactAfterRestore(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	p.Save()
+	p.SetFont(f, 40)
+	p.Left(72, 600, "Heading")
+	p.Restore()
+	p.Left(72, 700, "public secret")
+	// Cover "secret", using the 12-point text's real width.
+	_, public := p.measure("public ")
+	_, all := p.measure("public secret")
+	p.Redact(Rect{72 + public - 1, 690, 72 + all + 1, 715})
+
+	text := extracted(t, d)
+	if !strings.Contains(text, "public") || strings.Contains(text, "sec") {
+		t.Errorf("redacted text extracts as %q", text)
+	}
+}
@@ -0,0 +1,337 @@
+package pdf
+
+import "fmt"
+
+// A DocumentDef describes a document declaratively, so that it can be
+// loaded from JSON (or another configuration format) and drawn by Render.
+type DocumentDef struct {
+	Language string  `json:"language,omitempty"`
+	Margins  Margins `json:"margins"`
+
+	// Fonts maps the names used in StyleDef.Font to font filenames.
+	Fonts map[string]string `json:"fonts"`
+
+	// Styles holds named text styles, which elements refer to by name.
+	Styles map[string]StyleDef `json:"styles"`
+
+	// Pages are pages with explicitly positioned elements.
+	Pages []PageDef `json:"pages"`
+
+	// Flow, if it is not nil, describes content that is laid out on pages
+	// by a Flow. Its pages follow the ones in Pages.
+	Flow *FlowDef `json:"flow,omitempty"`
+}
+
+// A StyleDef describes a TextStyle.
+type StyleDef struct {
+	Font        string  `json:"font"`
+	Size        float64 `json:"size"`
+	Leading     float64 `json:"leading,omitempty"`
+	CharSpacing float64 `json:"charSpacing,omitempty"`
+
+	// Color is a gray, RGB, or CMYK color, depending on the number of
+	// components.
+	Color []float64 `json:"color,omitempty"`
+
+	// Align is "left", "center", or "right".
+	Align string `json:"align,omitempty"`
+}
+
+// A PageDef describes a page and the elements drawn on it.
+type PageDef struct {
+	Width    float64      `json:"width"`
+	Height   float64      `json:"height"`
+	Elements []ElementDef `json:"elements"`
+}
+
+// A FlowDef describes content laid out by a Flow.
+type FlowDef struct {
+	Width        float64      `json:"width"`
+	Height       float64      `json:"height"`
+	BodyStyle    string       `json:"bodyStyle"`
+	HeadingStyle string       `json:"headingStyle"`
+	Spacing      float64      `json:"spacing,omitempty"`
+	Content      []ElementDef `json:"content"`
+}
+
+// An ElementDef describes one element of a page or flow. Which fields are
+// used depends on Type:
+//
+//	"text"      Text at (X, Y), word-wrapped to Width if it is nonzero
+//	"rect"      a rectangle at (X, Y) of size Width×Height
+//	"line"      a line from (X, Y) to (X2, Y2)
+//	"image"     the image file Image, at (X, Y) of size Width×Height
+//	"table"     Rows, with ColumnWidths, with its top left corner at (X, Y)
+//	"paragraph" Text, as a paragraph (flows only)
+//	"heading"   Text, as a heading (flows only)
+//	"spacer"    Height units of vertical space (flows only)
+//	"pageBreak" a new page (flows only)
+//
+// In a flow, the position of an image or table is ignored, and an image's
+// height is calculated from its width.
+type ElementDef struct {
+	Type   string  `json:"type"`
+	X      float64 `json:"x,omitempty"`
+	Y      float64 `json:"y,omitempty"`
+	X2     float64 `json:"x2,omitempty"`
+	Y2     float64 `json:"y2,omitempty"`
+	Width  float64 `json:"width,omitempty"`
+	Height float64 `json:"height,omitempty"`
+
+	Text  string `json:"text,omitempty"`
+	Style string `json:"style,omitempty"`
+	Image string `json:"image,omitempty"`
+
+	Rows         [][]string `json:"rows,omitempty"`
+	ColumnWidths []float64  `json:"columnWidths,omitempty"`
+
+	// Fill and Stroke are the colors for a rect or line; if both are
+	// empty, a rect is stroked in the current color.
+	Fill      []float64 `json:"fill,omitempty"`
+	Stroke    []float64 `json:"stroke,omitempty"`
+	LineWidth float64   `json:"lineWidth,omitempty"`
+}
+
+// Render creates a new Document and draws the contents described by def on
+// it.
+func Render(def DocumentDef) (*Document, error) {
+	r := &renderer{
+		doc:    new(Document),
+		def:    def,
+		styles: make(map[string]TextStyle),
+	}
+	r.doc.SetLanguage(def.Language)
+	r.doc.SetMargins(def.Margins)
+
+	for name, sd := range def.Styles {
+		style, err := r.textStyle(sd)
+		if err != nil {
+			return nil, fmt.Errorf("style %q: %v", name, err)
+		}
+		r.styles[name] = style
+	}
+
+	for i, pd := range def.Pages {
+		p := r.doc.NewPage(pd.Width, pd.Height)
+		for j, el := range pd.Elements {
+			if err := r.drawElement(p, el); err != nil {
+				return nil, fmt.Errorf("page %d, element %d: %v", i+1, j+1, err)
+			}
+		}
+	}
+
+	if def.Flow != nil {
+		if err := r.renderFlow(def.Flow); err != nil {
+			return nil, err
+		}
+	}
+
+	return r.doc, nil
+}
+
+// A renderer holds the state used by Render.
+type renderer struct {
+	doc    *Document
+	def    DocumentDef
+	styles map[string]TextStyle
+}
+
+func (r *renderer) textStyle(sd StyleDef) (TextStyle, error) {
+	filename, ok := r.def.Fonts[sd.Font]
+	if !ok {
+		return TextStyle{}, fmt.Errorf("unknown font %q", sd.Font)
+	}
+	f, err := r.doc.LoadFont(filename)
+	if err != nil {
+		return TextStyle{}, err
+	}
+	style := TextStyle{
+		Font:        f,
+		Size:        sd.Size,
+		Leading:     sd.Leading,
+		CharSpacing: sd.CharSpacing,
+	}
+	if style.Leading == 0 {
+		style.Leading = sd.Size * 1.2
+	}
+	if style.Color, err = colorDef(sd.Color); err != nil {
+		return TextStyle{}, err
+	}
+	switch sd.Align {
+	case "", "left":
+		style.Alignment = AlignLeft
+	case "center":
+		style.Alignment = AlignCenter
+	case "right":
+		style.Alignment = AlignRight
+	default:
+		return TextStyle{}, fmt.Errorf("unknown alignment %q", sd.Align)
+	}
+	return style, nil
+}
+
+// colorDef converts a list of color components to a Color. It returns nil
+// for an empty list.
+func colorDef(c []float64) (Color, error) {
+	switch len(c) {
+	case 0:
+		return nil, nil
+	case 1:
+		return Gray(c[0]), nil
+	case 3:
+		return RGB{c[0], c[1], c[2]}, nil
+	case 4:
+		return CMYK{c[0], c[1], c[2], c[3]}, nil
+	}
+	return nil, fmt.Errorf("color must have 1, 3, or 4 components, not %d", len(c))
+}
+
+func (r *renderer) style(name string) (TextStyle, error) {
+	style, ok := r.styles[name]
+	if !ok {
+		return TextStyle{}, fmt.Errorf("unknown style %q", name)
+	}
+	return style, nil
+}
+
+func (r *renderer) drawElement(p *Page, el ElementDef) error {
+	switch el.Type {
+	case "text":
+		style, err := r.style(el.Style)
+		if err != nil {
+			return err
+		}
+		p.Save()
+		if el.Width > 0 {
+			p.WordWrapWithStyle(el.X, el.Y, el.Width, el.Text, style)
+		} else {
+			p.MultilineWithStyle(el.X, el.Y, el.Text, style)
+		}
+		p.Restore()
+
+	case "rect", "line":
+		fill, err := colorDef(el.Fill)
+		if err != nil {
+			return err
+		}
+		stroke, err := colorDef(el.Stroke)
+		if err != nil {
+			return err
+		}
+		p.Save()
+		if el.LineWidth != 0 {
+			p.SetLineWidth(el.LineWidth)
+		}
+		if stroke != nil {
+			p.SetStrokeColor(stroke)
+		}
+		if el.Type == "line" {
+			p.Line(el.X, el.Y, el.X2, el.Y2)
+		} else {
+			if fill != nil {
+				p.SetFillColor(fill)
+			}
+			p.Rectangle(el.X, el.Y, el.Width, el.Height)
+			switch {
+			case fill != nil && stroke != nil:
+				p.FillAndStroke()
+			case fill != nil:
+				p.Fill()
+			default:
+				p.Stroke()
+			}
+		}
+		p.Restore()
+
+	case "image":
+		img, err := r.doc.LoadImage(el.Image)
+		if err != nil {
+			return err
+		}
+		p.DrawImage(img, el.X, el.Y, el.Width, el.Height)
+
+	case "table":
+		style, err := r.style(el.Style)
+		if err != nil {
+			return err
+		}
+		p.Save()
+		p.SetTextStyle(style)
+		y := el.Y
+		for i, row := range el.Rows {
+			y = p.tableRow(el.X, y, row, el.ColumnWidths, i == 0)
+		}
+		p.Restore()
+
+	default:
+		return fmt.Errorf("unknown element type %q", el.Type)
+	}
+	return nil
+}
+
+// tableRow draws a table row in the current font, with its top left corner
+// at (x, y), and returns the y coordinate of its bottom. If rule is true, a
+// line is drawn under it.
+func (p *Page) tableRow(x, y float64, row []string, columnWidths []float64, rule bool) float64 {
+	const padding = 2
+	ascent, _ := p.font().metrics()
+	baseline := y - padding - float64(ascent)*0.001*p.currentSize
+	bottom := y
+	cellX := x
+	for i, cell := range row {
+		if i >= len(columnWidths) {
+			break
+		}
+		last := p.TextArea(cellX+padding, baseline, columnWidths[i]-2*padding, cell)
+		// Each line takes up one leading, as in Flow.rowHeight.
+		if b := y - 2*padding - (baseline - last) - p.leading; b < bottom {
+			bottom = b
+		}
+		cellX += columnWidths[i]
+	}
+	if rule {
+		p.HLine(x, cellX, bottom)
+	}
+	return bottom
+}
+
+func (r *renderer) renderFlow(fd *FlowDef) error {
+	f := NewFlow(r.doc, fd.Width, fd.Height)
+	f.Spacing = fd.Spacing
+	body, err := r.style(fd.BodyStyle)
+	if err != nil {
+		return fmt.Errorf("flow: %v", err)
+	}
+	f.BodyFont, f.BodySize, f.BodyLeading = body.Font, body.Size, body.Leading
+	f.BodyColor, f.BodyAlignment = body.Color, body.Alignment
+	heading, err := r.style(fd.HeadingStyle)
+	if err != nil {
+		return fmt.Errorf("flow: %v", err)
+	}
+	f.HeadingFont, f.HeadingSize, f.HeadingLeading = heading.Font, heading.Size, heading.Leading
+	f.HeadingColor, f.HeadingAlignment = heading.Color, heading.Alignment
+
+	for i, el := range fd.Content {
+		switch el.Type {
+		case "paragraph":
+			f.WriteParagraph(el.Text)
+		case "heading":
+			f.WriteHeading(el.Text)
+		case "table":
+			f.WriteTable(el.Rows, el.ColumnWidths)
+		case "image":
+			img, err := r.doc.LoadImage(el.Image)
+			if err != nil {
+				return fmt.Errorf("flow element %d: %v", i+1, err)
+			}
+			f.Image(img, el.Width)
+		case "spacer":
+			f.Spacer(el.Height)
+		case "pageBreak":
+			f.PageBreak()
+		default:
+			return fmt.Errorf("flow element %d: unknown element type %q", i+1, el.Type)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,85 @@
+package pdf
+
+import (
+	"bytes"
+	"io/ioutil"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/font/gofont/goregular"
+)
+
+func TestRenderFlowStyles(t *testing.T) {
+	longText := strings.Repeat("Centered lines of different lengths. ", 30)
+	longText = strings.TrimSpace(longText)
+
+	dir, err := ioutil.TempDir("", "pdf")
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer os.RemoveAll(dir)
+	fontFile := filepath.Join(dir, "regular.ttf")
+	if err := ioutil.WriteFile(fontFile, goregular.TTF, 0666); err != nil {
+		t.Fatal(err)
+	}
+
+	d, err := Render(DocumentDef{
+		Margins: Margins{Top: 72, Bottom: 72, Inner: 72, Outer: 72},
+		Fonts:   map[string]string{"regular": fontFile},
+		Styles: map[string]StyleDef{
+			"body":    {Font: "regular", Size: 10, Color: []float64{1, 0, 0}, Align: "center"},
+			"heading": {Font: "regular", Size: 20, Color: []float64{0, 0, 1}, Align: "right"},
+		},
+		Flow: &FlowDef{
+			Width: 612, Height: 792,
+			BodyStyle: "body", HeadingStyle: "heading",
+			Content: []ElementDef{
+				{Type: "heading", Text: "Title"},
+				{Type: "paragraph", Text: "Short"},
+				{Type: "pageBreak"},
+				{Type: "paragraph", Text: longText},
+			},
+		},
+	})
+	if err != nil {
+		t.Fatal(err)
+	}
+	content := d.Page(0).pageContent()
+	for _, color := range []string{"0 0 1 rg", "1 0 0 rg"} {
+		if !bytes.Contains(content, []byte(color)) {
+			t.Errorf("text color %q not set:\n%s", color, prettyContent(content))
+		}
+	}
+
+	// The x coordinates of the text, from the Td operators.
+	var xs []float64
+	tokens := pdfTokens(content)
+	for i, tok := range tokens {
+		if tok == "Td" && i >= 2 {
+			x, _ := strconv.ParseFloat(tokens[i-2], 64)
+			xs = append(xs, x)
+		}
+	}
+	f := d.fontCache[fontFile]
+	want := []float64{
+		540 - f.WrapLines("Title", 1000, 20)[0].Width,
+		306 - f.WrapLines("Short", 1000, 10)[0].Width/2,
+	}
+	if len(xs) != len(want) {
+		t.Fatalf("got text at x = %v, want %v", xs, want)
+	}
+	for i := range want {
+		if math.Abs(xs[i]-want[i]) > 0.01 {
+			t.Errorf("got text at x = %v, want %v", xs, want)
+		}
+	}
+
+	pages := strings.Split(extracted(t, d), "\f")
+	if got := strings.Join(strings.Fields(pages[len(pages)-1]), " "); got != longText {
+		t.Errorf("centered paragraph extracts as %q", got)
+	}
+}
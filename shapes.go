@@ -0,0 +1,145 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+)
+
+// These outlines are defined in a unit square, with the origin at the lower
+// left corner.
+var (
+	checkmarkOutline = [][2]float64{{0, 0.55}, {0.14, 0.69}, {0.38, 0.45}, {0.86, 0.93}, {1, 0.79}, {0.38, 0.17}}
+	crossOutline     = [][2]float64{{0, 0.15}, {0.15, 0}, {0.5, 0.35}, {0.85, 0}, {1, 0.15}, {0.65, 0.5}, {1, 0.85}, {0.85, 1}, {0.5, 0.65}, {0.15, 1}, {0, 0.85}, {0.35, 0.5}}
+	arrowOutline     = [][2]float64{{0, 0.35}, {0.6, 0.35}, {0.6, 0.1}, {1, 0.5}, {0.6, 0.9}, {0.6, 0.65}, {0, 0.65}}
+)
+
+// outline adds a closed subpath to the current path, made from points
+// scaled to size, with the origin at (x, y).
+func (p *Page) outline(x, y, size float64, points [][2]float64) {
+	for i, pt := range points {
+		if i == 0 {
+			p.MoveTo(x+pt[0]*size, y+pt[1]*size)
+		} else {
+			p.LineTo(x+pt[0]*size, y+pt[1]*size)
+		}
+	}
+	p.ClosePath()
+}
+
+// Checkmark adds the outline of a check mark to the current path. The check
+// mark fills a square with sides of length size and its lower left corner at
+// (x, y).
+func (p *Page) Checkmark(x, y, size float64) {
+	p.outline(x, y, size, checkmarkOutline)
+}
+
+// Cross adds the outline of an X to the current path. The X fills a square
+// with sides of length size and its lower left corner at (x, y).
+func (p *Page) Cross(x, y, size float64) {
+	p.outline(x, y, size, crossOutline)
+}
+
+// Arrow adds the outline of a right-pointing arrow to the current path. The
+// arrow fills a square with sides of length size and its lower left corner at
+// (x, y).
+func (p *Page) Arrow(x, y, size float64) {
+	p.outline(x, y, size, arrowOutline)
+}
+
+// Star adds the outline of a star to the current path, centered at (cx, cy),
+// with the given number of points. The tips of the points are at a distance
+// of outerR from the center, and the inner corners at innerR. The first point
+// is straight up. If points is less than 1, Star adds nothing to the path,
+// and the problem is reported by Document.Validate.
+func (p *Page) Star(cx, cy, outerR, innerR float64, points int) {
+	if points < 1 {
+		p.problems = append(p.problems, fmt.Sprintf("Star called with %d points", points))
+		return
+	}
+	for i := 0; i < points*2; i++ {
+		r := outerR
+		if i%2 == 1 {
+			r = innerR
+		}
+		angle := math.Pi/2 + float64(i)*math.Pi/float64(points)
+		x := cx + r*math.Cos(angle)
+		y := cy + r*math.Sin(angle)
+		if i == 0 {
+			p.MoveTo(x, y)
+		} else {
+			p.LineTo(x, y)
+		}
+	}
+	p.ClosePath()
+}
+
+// DrawMatrix fills a square of side moduleSize for each true value in
+// modules, as for a QR code or other two-dimensional barcode. modules[0] is
+// the top row, and (x, y) is the lower left corner of the whole matrix.
+// Adjacent modules in a row are merged into a single rectangle, and the whole
+// matrix is filled at once, with the current fill color.
+func (p *Page) DrawMatrix(x, y, moduleSize float64, modules [][]bool) {
+	drawn := false
+	for i, row := range modules {
+		rowY := y + float64(len(modules)-1-i)*moduleSize
+		for j := 0; j < len(row); j++ {
+			if !row[j] {
+				continue
+			}
+			start := j
+			for j < len(row) && row[j] {
+				j++
+			}
+			p.Rectangle(x+float64(start)*moduleSize, rowY, float64(j-start)*moduleSize, moduleSize)
+			drawn = true
+		}
+	}
+	if drawn {
+		p.Fill()
+	}
+}
+
+// RegularPolygon adds a regular polygon to the current path, centered at
+// (cx, cy), with its vertices at a distance of r from the center. With a
+// rotation of 0, the first vertex is straight up; rotationDeg rotates the
+// polygon counterclockwise by that many degrees. If sides is less than 3,
+// RegularPolygon does nothing.
+func (p *Page) RegularPolygon(cx, cy, r float64, sides int, rotationDeg float64) {
+	if sides < 3 {
+		return
+	}
+	start := math.Pi/2 + rotationDeg*math.Pi/180
+	for i := 0; i < sides; i++ {
+		angle := start + float64(i)*2*math.Pi/float64(sides)
+		x := cx + r*math.Cos(angle)
+		y := cy + r*math.Sin(angle)
+		if i == 0 {
+			p.MoveTo(x, y)
+		} else {
+			p.LineTo(x, y)
+		}
+	}
+	p.ClosePath()
+}
+
+// Pill adds a capsule shape to the current path: a rectangle with its lower
+// left corner at (x, y), whose shorter ends are replaced by semicircles. If
+// the rectangle is wider than it is tall, the rounded ends are on the left
+// and right; otherwise they are on the top and bottom.
+func (p *Page) Pill(x, y, width, height float64) {
+	r := math.Min(width, height) / 2
+	// k is the distance of a quarter circle's control points from its
+	// ends, for a Bézier approximation.
+	k := 0.5522847498 * r
+
+	p.MoveTo(x+r, y)
+	p.LineTo(x+width-r, y)
+	p.CurveTo(x+width-r+k, y, x+width, y+r-k, x+width, y+r)
+	p.LineTo(x+width, y+height-r)
+	p.CurveTo(x+width, y+height-r+k, x+width-r+k, y+height, x+width-r, y+height)
+	p.LineTo(x+r, y+height)
+	p.CurveTo(x+r-k, y+height, x, y+height-r+k, x, y+height-r)
+	p.LineTo(x, y+r)
+	p.CurveTo(x, y+r-k, x+r-k, y, x+r, y)
+	p.ClosePath()
+}
@@ -0,0 +1,102 @@
+package pdf
+
+import (
+	"bytes"
+	"fmt"
+	"strings"
+)
+
+// DocMDP permission levels, for SetDocMDP. They specify what changes may be
+// made to a certified document without invalidating the certification.
+const (
+	// MDPNoChanges allows no changes.
+	MDPNoChanges = 1
+
+	// MDPFormFilling allows filling in forms, instantiating page
+	// templates, and signing.
+	MDPFormFilling = 2
+
+	// MDPAnnotations allows what MDPFormFilling does, as well as adding,
+	// deleting, and modifying annotations.
+	MDPAnnotations = 3
+)
+
+// sigContentsSize is the number of bytes reserved for the signature in a
+// signature dictionary's /Contents.
+const sigContentsSize = 8192
+
+// byteRangePlaceholder is written in place of a signature's /ByteRange, to
+// be replaced with the actual values (padded with spaces) once the file is
+// complete.
+const byteRangePlaceholder = "[0 0000000000 0000000000 0000000000]"
+
+// SetDocMDP prepares the document to be certified with a DocMDP
+// (modification detection and prevention) signature, with the given
+// permission level (MDPNoChanges, MDPFormFilling, or MDPAnnotations). The
+// catalog gets a /Perms dictionary pointing to a signature dictionary,
+// which is the value of an invisible signature field on the first page.
+//
+// The package doesn't compute the signature itself. In the encoded file,
+// the signature dictionary's /ByteRange is already filled in, covering the
+// whole file except the /Contents hex string, which is filled with zeros.
+// An external signer should compute a detached PKCS#7 signature over the
+// byte range and write it in hex over the zeros, without changing the
+// length of the file. There is room for a signature of 8192 bytes.
+func (d *Document) SetDocMDP(permissions int) {
+	d.docMDP = &sigDict{permissions: permissions}
+}
+
+// A sigDict is a signature dictionary with a DocMDP transform.
+type sigDict struct {
+	permissions int
+}
+
+func (s *sigDict) writeTo(e *encoder) {
+	e.sigOffset = e.Len()
+	fmt.Fprintf(e, "<< /Type /Sig /Filter /Adobe.PPKLite /SubFilter /adbe.pkcs7.detached /ByteRange %s ", byteRangePlaceholder)
+	fmt.Fprintf(e, "/Reference [<< /Type /SigRef /TransformMethod /DocMDP /TransformParams << /Type /TransformParams /P %d /V /1.2 >> >>] ", s.permissions)
+	fmt.Fprintf(e, "/Contents <%s> >>", strings.Repeat("0", 2*sigContentsSize))
+}
+
+// A sigField is an invisible signature field, which is also its own widget
+// annotation.
+type sigField struct {
+	sig  *sigDict
+	page *Page
+}
+
+func (f *sigField) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Type /Annot /Subtype /Widget /FT /Sig /T (Signature1) /Rect [0 0 0 0] /F 132 /P %d 0 R /V %d 0 R >>", e.getRef(f.page), e.getRef(f.sig))
+}
+
+// writeSignatureEntries writes the catalog entries for a DocMDP signature.
+func (d *Document) writeSignatureEntries(e *encoder) {
+	if d.docMDP == nil || len(d.pages.pages) == 0 {
+		return
+	}
+	field := d.pages.pages[0].sigField
+	fmt.Fprintf(e, "/Perms << /DocMDP %d 0 R >> /AcroForm << /Fields [%d 0 R] /SigFlags 3 >> ", e.getRef(d.docMDP), e.getRef(field))
+}
+
+// fillByteRange replaces the /ByteRange placeholder in the encoded file b
+// with the offsets and lengths of the parts of the file before and after
+// the signature's /Contents. The signature dictionary starts at offset, so
+// the search for the placeholder starts there, rather than finding one that
+// happens to be in a string or raw object earlier in the file.
+func fillByteRange(b []byte, offset int) {
+	i := bytes.Index(b[offset:], []byte(byteRangePlaceholder))
+	if i == -1 {
+		return
+	}
+	i += offset
+	contents := bytes.Index(b[i:], []byte("/Contents <"))
+	if contents == -1 {
+		return
+	}
+	start := i + contents + len("/Contents ")
+	end := start + bytes.IndexByte(b[start:], '>') + 1
+
+	r := fmt.Sprintf("[0 %d %d %d]", start, end, len(b)-end)
+	r += strings.Repeat(" ", len(byteRangePlaceholder)-len(r))
+	copy(b[i:], r)
+}
@@ -0,0 +1,78 @@
+package pdf
+
+import (
+	"math"
+	"strconv"
+)
+
+// SetPixelSnap makes the page snap the coordinates of stroked paths to the
+// pixel grid of a display at the given resolution, so that thin lines
+// (such as 1-pixel table rules) are drawn crisply instead of being blurred
+// across two rows of pixels. A line whose width is an odd number of pixels
+// is centered on a pixel; one whose width is even is centered on a pixel
+// boundary. The end points of lines and curves and the corners of
+// rectangles are snapped; curves' control points are not. A dpi of 0 turns
+// snapping off.
+//
+// Snapping assumes that the page is displayed with its origin on a pixel
+// corner, and that coordinates haven't been scaled or rotated with
+// Transform (or moved by a fraction of a pixel with Translate).
+func (p *Page) SetPixelSnap(dpi float64) {
+	p.pixelSnap = dpi
+}
+
+// snapPath snaps the coordinates of the current path to the pixel grid, if
+// SetPixelSnap is on.
+func (p *Page) snapPath() {
+	if p.pixelSnap <= 0 || !p.pathOpen() {
+		return
+	}
+
+	pixel := 72 / p.pixelSnap
+	offset := 0.0
+	if n := math.Max(math.Round(p.state.lineWidth/pixel), 1); math.Mod(n, 2) == 1 {
+		offset = pixel / 2
+	}
+	snap := func(v float64) float64 {
+		return math.Round((v-offset)/pixel)*pixel + offset
+	}
+	snapY := func(y float64) float64 {
+		if p.topDown {
+			return p.height - snap(p.height-y)
+		}
+		return snap(y)
+	}
+
+	b := &p.contents.b
+	path := pdfTokens(b.Bytes()[p.pathStart:])
+	b.Truncate(p.pathStart)
+	var operands []string
+	for _, tok := range path {
+		if !isOperator(tok) {
+			operands = append(operands, tok)
+			continue
+		}
+		v := make([]float64, len(operands))
+		for i, s := range operands {
+			v[i], _ = strconv.ParseFloat(s, 64)
+		}
+		switch {
+		case (tok == "m" || tok == "l") && len(v) == 2,
+			(tok == "c" && len(v) == 6) || ((tok == "v" || tok == "y") && len(v) == 4):
+			n := len(v)
+			v[n-2], v[n-1] = snap(v[n-2]), snapY(v[n-1])
+			p.contents.num(v...)
+		case tok == "re" && len(v) == 4:
+			x0, y0 := snap(v[0]), snapY(v[1])
+			x1, y1 := snap(v[0]+v[2]), snapY(v[1]+v[3])
+			p.contents.num(x0, y0, x1-x0, y1-y0)
+		default:
+			for _, s := range operands {
+				b.WriteString(s)
+				b.WriteByte(' ')
+			}
+		}
+		p.contents.op(tok)
+		operands = operands[:0]
+	}
+}
@@ -0,0 +1,46 @@
+package pdf
+
+import "fmt"
+
+// A Stamp is the appearance of a rubber-stamp annotation, such as
+// "APPROVED" or "RECEIVED", which can be placed on any number of pages with
+// PlaceStamp. Unlike a watermark drawn into the page content, a stamp is an
+// annotation, which viewers let the reader select, move, hide, or delete.
+// Its appearance is drawn with the methods of the embedded Template, and
+// stored once however many times it is placed.
+type Stamp struct {
+	*Template
+	name string
+}
+
+// NewStamp returns a new Stamp with the given size. The name identifies
+// the kind of stamp (such as "Approved" or "Draft", one of the standard
+// stamp names from the PDF specification, or any other name); some viewers
+// show it in the stamp's tooltip or use it to find a matching stamp of
+// their own.
+func (d *Document) NewStamp(name string, width, height float64) *Stamp {
+	return &Stamp{
+		Template: NewTemplate(width, height),
+		name:     name,
+	}
+}
+
+// A stampAnnot is a Stamp placed on a page.
+type stampAnnot struct {
+	stamp *Stamp
+	rect  Rect
+}
+
+func (a *stampAnnot) writeTo(e *encoder) {
+	fmt.Fprintf(e, "<< /Type /Annot /Subtype /Stamp /Rect %s /Name %s /F 4 /AP << /N %d 0 R >> >>", a.rect, formatName(a.stamp.name), e.getRef(a.stamp.Template))
+}
+
+// PlaceStamp adds a stamp annotation to the page, with its lower left
+// corner at (x, y) (or its top left corner, if p is in top-down mode).
+func (p *Page) PlaceStamp(stamp *Stamp, x, y float64) {
+	r := RectXYWH(x, y, stamp.width, stamp.height)
+	if p.topDown {
+		r = RectXYWH(x, p.height-y-stamp.height, stamp.width, stamp.height)
+	}
+	p.stamps = append(p.stamps, &stampAnnot{stamp, r})
+}
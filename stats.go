@@ -0,0 +1,106 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+)
+
+// pageContent returns the page's content streams joined together, including
+// the background image and the running headers and footers if the document
+// has been encoded. If the page has redactions, they are applied.
+func (p *Page) pageContent() []byte {
+	b := new(bytes.Buffer)
+	if p.background != nil {
+		b.Write(p.background.b.Bytes())
+		b.WriteByte('\n')
+	}
+	if p.decorations != nil {
+		b.Write(p.decorations.b.Bytes())
+		b.WriteByte('\n')
+	}
+	for _, f := range p.fragments {
+		b.Write(f.b.Bytes())
+		b.WriteByte('\n')
+	}
+	if len(p.redactions) > 0 {
+		return p.redactedContent(b.Bytes())
+	}
+	return b.Bytes()
+}
+
+// ContentSize returns the size in bytes of p's content streams, before and
+// after compression. The background image, headers, footers, and page
+// numbers are included only after the document has been encoded, since that
+// is when they are drawn.
+func (p *Page) ContentSize() (uncompressed, compressed int) {
+	for _, f := range append(p.fragments, p.decorations, p.background) {
+		if f == nil {
+			continue
+		}
+		uncompressed += f.b.Len()
+		cb := new(bytes.Buffer)
+		zw := zlib.NewWriter(cb)
+		zw.Write(f.b.Bytes())
+		zw.Close()
+		compressed += cb.Len()
+	}
+	return uncompressed, compressed
+}
+
+// OperatorCount returns the number of operators in p's content streams.
+func (p *Page) OperatorCount() int {
+	n := 0
+	for _, tok := range pdfTokens(p.pageContent()) {
+		if isOperator(tok) {
+			n++
+		}
+	}
+	return n
+}
+
+// An ObjectSize is an entry in the list returned by Document.Stats.
+type ObjectSize struct {
+	// Number is the object number.
+	Number int
+
+	// Kind is a rough classification of the object: "font", "image",
+	// "content" (for page content streams), "page", or "other".
+	Kind string
+
+	// Size is the number of bytes the object takes up in the file.
+	Size int
+}
+
+// Stats encodes the document and returns the size of each object in the
+// encoded file, to help find out where the bytes are going.
+func (d *Document) Stats() []ObjectSize {
+	e, b := d.encode()
+	end := bytes.LastIndex(b, []byte("xref\n"))
+
+	stats := make([]ObjectSize, len(e.objects))
+	for i, o := range e.objects {
+		next := end
+		if i+1 < len(e.offsets) {
+			next = e.offsets[i+1]
+		}
+		kind := "other"
+		switch o := o.(type) {
+		case *Font, *charProcs, *type3Glyph:
+			kind = "font"
+		case *Image:
+			kind = "image"
+		case *Page:
+			kind = "page"
+		case *stream:
+			if o.content {
+				kind = "content"
+			}
+		}
+		stats[i] = ObjectSize{
+			Number: i + 1,
+			Kind:   kind,
+			Size:   next - e.offsets[i],
+		}
+	}
+	return stats
+}
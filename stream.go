@@ -3,45 +3,259 @@ package pdf
 import (
 	"bytes"
 	"compress/zlib"
+	"encoding/ascii85"
+	"encoding/hex"
 	"fmt"
+	"strings"
 )
 
 type stream struct {
 	b bytes.Buffer
 
 	extraData string
+
+	// filter is the name of a filter that has already been applied to the
+	// data in b (such as /DCTDecode for JPEG images). If it is set, the data
+	// is not compressed again.
+	filter string
+
+	// decodeParms is the parameter dictionary for filter, if it needs one.
+	decodeParms string
+
+	// content is set for content streams, which are reformatted in pretty
+	// mode.
+	content bool
 }
 
 func (s *stream) Write(p []byte) (n int, err error) {
 	return s.b.Write(p)
 }
 
+// An ASCIIFilter is an encoding that can be applied to stream data to make a
+// PDF file 7-bit clean.
+type ASCIIFilter int
+
+const (
+	// NoASCIIFilter leaves stream data as binary.
+	NoASCIIFilter ASCIIFilter = iota
+
+	// ASCIIHex encodes stream data as hexadecimal digits (/ASCIIHexDecode).
+	ASCIIHex
+
+	// ASCII85 encodes stream data in base-85 (/ASCII85Decode). It is more
+	// compact than ASCIIHex.
+	ASCII85
+)
+
+// SetASCIIFilter sets an encoding to be applied to all streams in the
+// document (after compression, if any), so that the encoded file contains
+// only 7-bit ASCII characters.
+func (d *Document) SetASCIIFilter(f ASCIIFilter) {
+	d.asciiFilter = f
+}
+
+// A Compression is an algorithm for compressing streams.
+type Compression int
+
+const (
+	// CompressFlate uses Flate (zlib/deflate) compression (/FlateDecode).
+	// It is the default, and the best choice for almost all uses.
+	CompressFlate Compression = iota
+
+	// CompressLZW uses LZW compression (/LZWDecode). It usually compresses
+	// less well than Flate, but some very old readers support it better.
+	CompressLZW
+
+	// CompressNone leaves streams uncompressed, for pipelines that require
+	// it. Files are much larger, but their contents are easy to inspect.
+	CompressNone
+)
+
+// SetCompression sets the algorithm used to compress the document's streams.
+// Streams that already have a filter of their own (such as JPEG images) are
+// not affected.
+func (d *Document) SetCompression(c Compression) {
+	d.compression = c
+}
+
+// SetCompressionThreshold sets the minimum size (in bytes) of a stream that
+// will be considered for compression. Streams at least this large are
+// compressed if compression makes them smaller, taking into account the
+// extra space needed in the stream dictionary to declare the filter. The
+// default is 0, which considers every stream.
+func (d *Document) SetCompressionThreshold(n int) {
+	d.compressionThreshold = n
+}
+
 func (s *stream) writeTo(e *encoder) {
-	compressed := false
-	cb := new(bytes.Buffer)
-	zw := zlib.NewWriter(cb)
-	if _, err := zw.Write(s.b.Bytes()); err == nil {
-		if err := zw.Close(); err == nil {
-			if cb.Len() < s.b.Len()-len("/Filter /FlateDecode ") {
-				compressed = true
+	raw := s.b.Bytes()
+	if e.pretty && s.content {
+		raw = prettyContent(raw)
+	}
+	data, dict := s.encode(e, raw, CompressNone)
+
+	if s.filter == "" && len(raw) >= e.compressionThreshold && !e.pretty && e.compression != CompressNone {
+		if compressed, err := compress(raw, e.compression); err == nil {
+			compressedData, compressedDict := s.encode(e, compressed, e.compression)
+			if len(compressedData)+len(compressedDict) < len(data)+len(dict) {
+				data, dict = compressedData, compressedDict
 			}
 		}
 	}
 
-	if compressed {
-		fmt.Fprintf(e, "<< /Length %d /Filter /FlateDecode ", cb.Len())
-	} else {
-		fmt.Fprintf(e, "<< /Length %d ", s.b.Len())
-	}
+	fmt.Fprintf(e, "<< %s", dict)
 	if s.extraData != "" {
 		fmt.Fprint(e, s.extraData, " ")
 	}
 	fmt.Fprintln(e, ">>")
 	e.WriteString("stream\n")
-	if compressed {
-		e.Write(cb.Bytes())
-	} else {
-		e.Write(s.b.Bytes())
-	}
+	e.Write(data)
 	e.WriteString("\nendstream")
 }
+
+// compress compresses data with the algorithm c.
+func compress(data []byte, c Compression) ([]byte, error) {
+	b := new(bytes.Buffer)
+	if c == CompressLZW {
+		return lzwEncode(data), nil
+	}
+	w := zlib.NewWriter(b)
+	if _, err := w.Write(data); err != nil {
+		return nil, err
+	}
+	if err := w.Close(); err != nil {
+		return nil, err
+	}
+	return b.Bytes(), nil
+}
+
+// encode applies e's ASCII filter (if any) to data, which has been
+// compressed with the algorithm c. It returns the encoded data, and the
+// stream dictionary entries that describe it.
+func (s *stream) encode(e *encoder, data []byte, c Compression) (encoded []byte, dict string) {
+	var filters, parms []string
+	switch e.asciiFilter {
+	case ASCIIHex:
+		encoded = make([]byte, hex.EncodedLen(len(data)), hex.EncodedLen(len(data))+1)
+		hex.Encode(encoded, data)
+		encoded = append(encoded, '>')
+		filters = append(filters, "/ASCIIHexDecode")
+		parms = append(parms, "null")
+	case ASCII85:
+		encoded = make([]byte, ascii85.MaxEncodedLen(len(data)), ascii85.MaxEncodedLen(len(data))+2)
+		encoded = encoded[:ascii85.Encode(encoded, data)]
+		encoded = append(encoded, '~', '>')
+		filters = append(filters, "/ASCII85Decode")
+		parms = append(parms, "null")
+	default:
+		encoded = data
+	}
+	switch c {
+	case CompressFlate:
+		filters = append(filters, "/FlateDecode")
+		parms = append(parms, "null")
+	case CompressLZW:
+		filters = append(filters, "/LZWDecode")
+		parms = append(parms, "null")
+	}
+	if s.filter != "" {
+		filters = append(filters, s.filter)
+		parms = append(parms, "null")
+		if s.decodeParms != "" {
+			parms[len(parms)-1] = s.decodeParms
+		}
+	}
+
+	dict = fmt.Sprintf("/Length %d ", len(encoded))
+	switch len(filters) {
+	case 0:
+	case 1:
+		dict += fmt.Sprintf("/Filter %s ", filters[0])
+	default:
+		dict += fmt.Sprintf("/Filter %s ", filters)
+	}
+	hasParms := false
+	for _, p := range parms {
+		if p != "null" {
+			hasParms = true
+		}
+	}
+	switch {
+	case !hasParms:
+	case len(parms) == 1:
+		dict += fmt.Sprintf("/DecodeParms %s ", parms[0])
+	default:
+		dict += fmt.Sprintf("/DecodeParms [%s] ", strings.Join(parms, " "))
+	}
+	return encoded, dict
+}
+
+// lzwEncode compresses data with LZW the way /LZWDecode expects by default
+// (with an /EarlyChange of 1): the code width increases one code earlier
+// than with compress/lzw, which is why that package can't be used.
+func lzwEncode(data []byte) []byte {
+	const (
+		clearCode = 256
+		eodCode   = 257
+		maxWidth  = 12
+	)
+	out := new(bytes.Buffer)
+	var bits uint32
+	var nBits uint
+	width := uint(9)
+	write := func(code int) {
+		bits = bits<<width | uint32(code)
+		nBits += width
+		for nBits >= 8 {
+			nBits -= 8
+			out.WriteByte(byte(bits >> nBits))
+		}
+		bits &= 1<<nBits - 1
+	}
+
+	// table maps a code followed by a byte to the code for that sequence.
+	table := make(map[int]int)
+	next := eodCode + 1
+	write(clearCode)
+	prefix := -1
+	for _, c := range data {
+		if prefix < 0 {
+			prefix = int(c)
+			continue
+		}
+		key := prefix<<8 | int(c)
+		if code, ok := table[key]; ok {
+			prefix = code
+			continue
+		}
+		write(prefix)
+		prefix = int(c)
+		table[key] = next
+		next++
+		if next >= 1<<width {
+			if width == maxWidth {
+				// The table is full; start over.
+				write(clearCode)
+				table = make(map[int]int)
+				next = eodCode + 1
+				width = 9
+			} else {
+				width++
+			}
+		}
+	}
+	if prefix >= 0 {
+		write(prefix)
+		// The decoder counts this code as making a table entry too, so
+		// the end code may need to be wider.
+		next++
+		if next >= 1<<width && width < maxWidth {
+			width++
+		}
+	}
+	write(eodCode)
+	if nBits > 0 {
+		out.WriteByte(byte(bits << (8 - nBits)))
+	}
+	return out.Bytes()
+}
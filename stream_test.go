@@ -0,0 +1,122 @@
+package pdf
+
+import (
+	"bytes"
+	"compress/zlib"
+	"fmt"
+	"io/ioutil"
+	"math/rand"
+	"strings"
+	"testing"
+
+	"golang.org/x/image/tiff/lzw"
+)
+
+// encodeStream returns the encoding of a stream containing data.
+func encodeStream(data []byte, threshold int) string {
+	e := &encoder{compressionThreshold: threshold}
+	s := new(stream)
+	s.Write(data)
+	s.writeTo(e)
+	return e.String()
+}
+
+// streamTestData returns n bytes that compress somewhat, but not
+// dramatically, so that the better choice depends on the length.
+func streamTestData(n int) []byte {
+	r := rand.New(rand.NewSource(int64(n)))
+	b := make([]byte, n)
+	for i := range b {
+		b[i] = "abcdefgh"[r.Intn(8)]
+	}
+	return b
+}
+
+func TestCompressionChoosesSmaller(t *testing.T) {
+	sawCompressed, sawUncompressed := false, false
+	for n := 0; n < 300; n++ {
+		data := streamTestData(n)
+		plain := fmt.Sprintf("<< /Length %d >>\nstream\n%s\nendstream", n, data)
+
+		b := new(bytes.Buffer)
+		zw := zlib.NewWriter(b)
+		zw.Write(data)
+		zw.Close()
+		compressed := fmt.Sprintf("<< /Length %d /Filter /FlateDecode >>\nstream\n%s\nendstream", b.Len(), b.Bytes())
+
+		want := plain
+		if len(compressed) < len(plain) {
+			want = compressed
+		}
+		got := encodeStream(data, 0)
+		if got != want {
+			t.Fatalf("%d bytes: got %d-byte stream, want %d (uncompressed %d, compressed %d)", n, len(got), len(want), len(plain), len(compressed))
+		}
+		if strings.Contains(got, "/FlateDecode") {
+			sawCompressed = true
+		} else {
+			sawUncompressed = true
+		}
+	}
+	if !sawCompressed || !sawUncompressed {
+		t.Error("test data doesn't cross the point where compression pays off")
+	}
+}
+
+func TestCompressionThreshold(t *testing.T) {
+	data := bytes.Repeat([]byte("compressible "), 20)
+	n := len(data)
+	tests := []struct {
+		threshold  int
+		compressed bool
+	}{
+		{0, true},
+		{n - 1, true},
+		{n, true},
+		{n + 1, false},
+	}
+	for _, test := range tests {
+		got := strings.Contains(encodeStream(data, test.threshold), "/FlateDecode")
+		if got != test.compressed {
+			t.Errorf("%d-byte stream, threshold %d: compressed = %v, want %v", n, test.threshold, got, test.compressed)
+		}
+	}
+}
+
+func TestLZWEarlyChange(t *testing.T) {
+	// TIFF uses the same variant of LZW as the PDF default, so its decoder
+	// can check the encoding.
+	r := rand.New(rand.NewSource(1))
+	random := make([]byte, 100000)
+	r.Read(random)
+	inputs := [][]byte{
+		nil,
+		[]byte("a"),
+		[]byte("TOBEORNOTTOBEORTOBEORNOT"),
+		bytes.Repeat([]byte("ab"), 1000),
+		streamTestData(300),
+		streamTestData(100000),
+		random,
+	}
+	for n := 250; n < 270; n++ {
+		inputs = append(inputs, streamTestData(n))
+	}
+	for _, data := range inputs {
+		decoded, err := ioutil.ReadAll(lzw.NewReader(bytes.NewReader(lzwEncode(data)), lzw.MSB, 8))
+		if err != nil {
+			t.Errorf("%d bytes: %v", len(data), err)
+			continue
+		}
+		if !bytes.Equal(decoded, data) {
+			t.Errorf("%d bytes: decoded data doesn't match", len(data))
+		}
+	}
+
+	e := &encoder{compression: CompressLZW}
+	s := new(stream)
+	s.Write(streamTestData(1000))
+	s.writeTo(e)
+	if got := e.String(); !strings.Contains(got, "/Filter /LZWDecode") || strings.Contains(got, "/DecodeParms") {
+		t.Errorf("stream dictionary should use /LZWDecode with the default parameters:\n%s", got[:strings.Index(got, "stream")])
+	}
+}
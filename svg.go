@@ -0,0 +1,731 @@
+package pdf
+
+import (
+	"bytes"
+	"encoding/xml"
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// DrawSVG draws the SVG image in svg, scaled (with its aspect ratio
+// preserved) and centered to fit the box with its lower left corner at
+// (x, y). Only a simple subset of SVG is supported: the rect, circle,
+// ellipse, line, polyline, polygon, and path elements, g elements with
+// transforms, and solid fill and stroke colors with stroke-width and
+// fill-rule. Other elements are skipped, and reported by Document.Validate.
+// An error is returned only if svg is not well-formed XML.
+func (p *Page) DrawSVG(x, y, width, height float64, svg []byte) error {
+	d := xml.NewDecoder(bytes.NewReader(svg))
+	r := &svgRenderer{page: p}
+	p.Save()
+	defer func() {
+		// If the SVG ends in the middle, some elements are still open.
+		for ; r.saves > 0; r.saves-- {
+			p.Restore()
+		}
+		p.Restore()
+	}()
+
+	depth := 0
+	for {
+		tok, err := d.Token()
+		if err != nil {
+			if depth == 0 && r.started {
+				return nil
+			}
+			return fmt.Errorf("pdf: parsing SVG: %v", err)
+		}
+		switch tok := tok.(type) {
+		case xml.StartElement:
+			if !r.started {
+				if tok.Name.Local != "svg" {
+					return fmt.Errorf("pdf: root element is %s, not svg", tok.Name.Local)
+				}
+				r.started = true
+				r.setViewport(tok, x, y, width, height)
+			}
+			r.start(tok)
+			depth++
+		case xml.EndElement:
+			r.end()
+			depth--
+			if depth == 0 {
+				return nil
+			}
+		}
+	}
+}
+
+// svgStyle holds the inherited painting properties of an SVG element.
+type svgStyle struct {
+	fill, stroke Color // nil for none
+	strokeWidth  float64
+	evenOdd      bool
+}
+
+// An svgRenderer draws SVG elements as they are read.
+type svgRenderer struct {
+	page    *Page
+	started bool
+
+	// styles is a stack with an entry for each open element.
+	styles []svgStyle
+
+	// skip is the depth of nesting inside an element that is being
+	// skipped, or 0 if no element is being skipped.
+	skip int
+
+	// saves is the number of open elements that have saved the graphics
+	// state.
+	saves int
+}
+
+// setViewport sets up the coordinate system for the root svg element.
+func (r *svgRenderer) setViewport(el xml.StartElement, x, y, width, height float64) {
+	p := r.page
+	var vb []float64
+	if v, ok := svgAttr(el, "viewBox"); ok {
+		vb = svgNumbers(v)
+	}
+	if len(vb) != 4 {
+		w, _ := svgAttr(el, "width")
+		h, _ := svgAttr(el, "height")
+		vb = []float64{0, 0, svgLength(w), svgLength(h)}
+	}
+	if vb[2] <= 0 || vb[3] <= 0 {
+		vb[2], vb[3] = width, height
+	}
+
+	scale := math.Min(width/vb[2], height/vb[3])
+	offsetX := x + (width-vb[2]*scale)/2
+	offsetY := y + (height-vb[3]*scale)/2
+	// SVG's y axis points down, so the coordinate system is flipped.
+	p.Transform(scale, 0, 0, -scale, offsetX-vb[0]*scale, offsetY+(vb[1]+vb[3])*scale)
+}
+
+func (r *svgRenderer) start(el xml.StartElement) {
+	if r.skip > 0 {
+		r.skip++
+		return
+	}
+
+	style := svgStyle{fill: Gray(0), strokeWidth: 1}
+	if len(r.styles) > 0 {
+		style = r.styles[len(r.styles)-1]
+	}
+	r.applyStyle(&style, el)
+	r.styles = append(r.styles, style)
+
+	switch el.Name.Local {
+	case "svg", "g", "rect", "circle", "ellipse", "line", "polyline", "polygon", "path":
+	case "title", "desc", "metadata", "defs":
+		// Not rendered, so not a problem.
+		r.skip = 1
+		return
+	default:
+		r.warn("unsupported element <%s> skipped", el.Name.Local)
+		r.skip = 1
+		return
+	}
+
+	p := r.page
+	p.Save()
+	r.saves++
+	if t, ok := svgAttr(el, "transform"); ok {
+		r.transform(t)
+	}
+	if el.Name.Local == "svg" || el.Name.Local == "g" {
+		return
+	}
+	if r.shape(el) {
+		r.paint(style)
+	} else {
+		p.contents.op("n")
+	}
+}
+
+func (r *svgRenderer) end() {
+	if r.skip > 0 {
+		r.skip--
+		if r.skip == 0 {
+			r.styles = r.styles[:len(r.styles)-1]
+		}
+		return
+	}
+	r.styles = r.styles[:len(r.styles)-1]
+	r.page.Restore()
+	r.saves--
+}
+
+func (r *svgRenderer) warn(format string, args ...interface{}) {
+	r.page.problems = append(r.page.problems, "SVG: "+fmt.Sprintf(format, args...))
+}
+
+// applyStyle updates style with the presentation attributes and style
+// properties of el.
+func (r *svgRenderer) applyStyle(style *svgStyle, el xml.StartElement) {
+	props := make(map[string]string)
+	for _, a := range el.Attr {
+		props[a.Name.Local] = a.Value
+	}
+	if s, ok := props["style"]; ok {
+		for _, decl := range strings.Split(s, ";") {
+			if i := strings.Index(decl, ":"); i != -1 {
+				props[strings.TrimSpace(decl[:i])] = strings.TrimSpace(decl[i+1:])
+			}
+		}
+	}
+
+	if v, ok := props["fill"]; ok {
+		style.fill = r.color(v, style.fill)
+	}
+	if v, ok := props["stroke"]; ok {
+		style.stroke = r.color(v, style.stroke)
+	}
+	if v, ok := props["stroke-width"]; ok {
+		style.strokeWidth = svgLength(v)
+	}
+	if v, ok := props["fill-rule"]; ok {
+		style.evenOdd = v == "evenodd"
+	}
+}
+
+// svgNamedColors are the SVG color keywords that are supported.
+var svgNamedColors = map[string]RGB{
+	"black":   {0, 0, 0},
+	"white":   {1, 1, 1},
+	"red":     {1, 0, 0},
+	"lime":    {0, 1, 0},
+	"green":   {0, 0.50196, 0},
+	"blue":    {0, 0, 1},
+	"yellow":  {1, 1, 0},
+	"cyan":    {0, 1, 1},
+	"magenta": {1, 0, 1},
+	"gray":    {0.50196, 0.50196, 0.50196},
+	"grey":    {0.50196, 0.50196, 0.50196},
+	"silver":  {0.75294, 0.75294, 0.75294},
+	"maroon":  {0.50196, 0, 0},
+	"navy":    {0, 0, 0.50196},
+	"olive":   {0.50196, 0.50196, 0},
+	"purple":  {0.50196, 0, 0.50196},
+	"teal":    {0, 0.50196, 0.50196},
+	"orange":  {1, 0.64706, 0},
+}
+
+// color parses an SVG paint value. If it is not supported, it returns
+// inherited.
+func (r *svgRenderer) color(v string, inherited Color) Color {
+	v = strings.TrimSpace(v)
+	switch {
+	case v == "none":
+		return nil
+	case v == "currentColor" || v == "inherit":
+		return inherited
+	case strings.HasPrefix(v, "#"):
+		hex := v[1:]
+		if len(hex) == 3 {
+			hex = string([]byte{hex[0], hex[0], hex[1], hex[1], hex[2], hex[2]})
+		}
+		if n, err := strconv.ParseUint(hex, 16, 32); err == nil && len(hex) == 6 {
+			return RGB{float64(n>>16) / 255, float64(n>>8&0xff) / 255, float64(n&0xff) / 255}
+		}
+	case strings.HasPrefix(v, "rgb(") && strings.HasSuffix(v, ")"):
+		parts := strings.Split(v[4:len(v)-1], ",")
+		if len(parts) == 3 {
+			var c [3]float64
+			for i, s := range parts {
+				s = strings.TrimSpace(s)
+				if strings.HasSuffix(s, "%") {
+					f, _ := strconv.ParseFloat(s[:len(s)-1], 64)
+					c[i] = f / 100
+				} else {
+					f, _ := strconv.ParseFloat(s, 64)
+					c[i] = f / 255
+				}
+			}
+			return RGB{c[0], c[1], c[2]}
+		}
+	default:
+		if c, ok := svgNamedColors[strings.ToLower(v)]; ok {
+			return c
+		}
+	}
+	r.warn("unsupported paint %q", v)
+	return inherited
+}
+
+// paint fills and/or strokes the current path according to style.
+func (r *svgRenderer) paint(style svgStyle) {
+	p := r.page
+	if style.fill != nil {
+		p.SetFillColor(style.fill)
+	}
+	if style.stroke != nil {
+		p.SetStrokeColor(style.stroke)
+		p.SetLineWidth(style.strokeWidth)
+	}
+	op := "n"
+	switch {
+	case style.fill != nil && style.stroke != nil:
+		op = "B"
+	case style.fill != nil:
+		op = "f"
+	case style.stroke != nil:
+		op = "S"
+	}
+	if style.evenOdd && op != "S" && op != "n" {
+		op += "*"
+	}
+	p.contents.op(op)
+}
+
+// shape adds the path for a basic shape or path element. It returns false
+// if there is nothing to draw.
+func (r *svgRenderer) shape(el xml.StartElement) bool {
+	p := r.page
+	num := func(name string) float64 {
+		v, _ := svgAttr(el, name)
+		return svgLength(v)
+	}
+
+	switch el.Name.Local {
+	case "rect":
+		x, y, w, h := num("x"), num("y"), num("width"), num("height")
+		if w <= 0 || h <= 0 {
+			return false
+		}
+		rx, hasRX := svgAttr(el, "rx")
+		ry, hasRY := svgAttr(el, "ry")
+		switch {
+		case hasRX && !hasRY:
+			ry = rx
+		case hasRY && !hasRX:
+			rx = ry
+		}
+		if svgLength(rx) > 0 {
+			svgRoundedRect(p, x, y, w, h, math.Min(svgLength(rx), w/2), math.Min(svgLength(ry), h/2))
+		} else {
+			p.Rectangle(x, y, w, h)
+		}
+
+	case "circle":
+		if num("r") <= 0 {
+			return false
+		}
+		p.circle(num("cx"), num("cy"), num("r"))
+
+	case "ellipse":
+		if num("rx") <= 0 || num("ry") <= 0 {
+			return false
+		}
+		svgEllipse(p, num("cx"), num("cy"), num("rx"), num("ry"))
+
+	case "line":
+		p.MoveTo(num("x1"), num("y1"))
+		p.LineTo(num("x2"), num("y2"))
+
+	case "polyline", "polygon":
+		v, _ := svgAttr(el, "points")
+		points := svgNumbers(v)
+		if len(points) < 4 {
+			return false
+		}
+		p.MoveTo(points[0], points[1])
+		for i := 2; i+1 < len(points); i += 2 {
+			p.LineTo(points[i], points[i+1])
+		}
+		if el.Name.Local == "polygon" {
+			p.ClosePath()
+		}
+
+	case "path":
+		v, _ := svgAttr(el, "d")
+		if err := svgPath(p, v); err != nil {
+			r.warn("%v", err)
+		}
+	}
+	return true
+}
+
+// transform applies an SVG transform attribute.
+func (r *svgRenderer) transform(t string) {
+	for {
+		t = strings.TrimLeft(t, " \t\r\n,")
+		open := strings.Index(t, "(")
+		close := strings.Index(t, ")")
+		if open == -1 || close < open {
+			return
+		}
+		name := strings.TrimSpace(t[:open])
+		args := svgNumbers(t[open+1 : close])
+		t = t[close+1:]
+		arg := func(i int, def float64) float64 {
+			if i < len(args) {
+				return args[i]
+			}
+			return def
+		}
+
+		p := r.page
+		switch name {
+		case "matrix":
+			if len(args) == 6 {
+				p.Transform(args[0], args[1], args[2], args[3], args[4], args[5])
+			}
+		case "translate":
+			p.Transform(1, 0, 0, 1, arg(0, 0), arg(1, 0))
+		case "scale":
+			sx := arg(0, 1)
+			p.Transform(sx, 0, 0, arg(1, sx), 0, 0)
+		case "rotate":
+			a := arg(0, 0) * math.Pi / 180
+			cx, cy := arg(1, 0), arg(2, 0)
+			p.Transform(1, 0, 0, 1, cx, cy)
+			p.Transform(math.Cos(a), math.Sin(a), -math.Sin(a), math.Cos(a), 0, 0)
+			p.Transform(1, 0, 0, 1, -cx, -cy)
+		case "skewX":
+			p.Transform(1, 0, math.Tan(arg(0, 0)*math.Pi/180), 1, 0, 0)
+		case "skewY":
+			p.Transform(1, math.Tan(arg(0, 0)*math.Pi/180), 0, 1, 0, 0)
+		default:
+			r.warn("unsupported transform %s", name)
+		}
+	}
+}
+
+func svgAttr(el xml.StartElement, name string) (string, bool) {
+	for _, a := range el.Attr {
+		if a.Name.Local == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// svgLength parses a length, ignoring any units.
+func svgLength(s string) float64 {
+	s = strings.TrimSpace(s)
+	end := 0
+	for end < len(s) && strings.IndexByte("0123456789+-.eE", s[end]) >= 0 {
+		end++
+	}
+	v, _ := strconv.ParseFloat(s[:end], 64)
+	return v
+}
+
+// svgNumbers parses a list of numbers separated by whitespace and/or
+// commas.
+func svgNumbers(s string) []float64 {
+	sc := svgScanner{s: s}
+	var list []float64
+	for {
+		v, ok := sc.number()
+		if !ok {
+			return list
+		}
+		list = append(list, v)
+	}
+}
+
+// An svgScanner reads the numbers and commands of SVG path data.
+type svgScanner struct {
+	s   string
+	pos int
+}
+
+func (sc *svgScanner) skipSpace() {
+	for sc.pos < len(sc.s) && strings.IndexByte(" \t\r\n,", sc.s[sc.pos]) >= 0 {
+		sc.pos++
+	}
+}
+
+// number reads a number. A number ends at a second decimal point, or at a
+// sign that doesn't follow an exponent marker, so "1.5.5-2" is three
+// numbers.
+func (sc *svgScanner) number() (float64, bool) {
+	sc.skipSpace()
+	start := sc.pos
+	i := sc.pos
+	if i < len(sc.s) && (sc.s[i] == '+' || sc.s[i] == '-') {
+		i++
+	}
+	digits, dot := false, false
+scan:
+	for i < len(sc.s) {
+		c := sc.s[i]
+		switch {
+		case c >= '0' && c <= '9':
+			digits = true
+		case c == '.' && !dot:
+			dot = true
+		case (c == 'e' || c == 'E') && digits:
+			if i+1 < len(sc.s) && (sc.s[i+1] == '+' || sc.s[i+1] == '-') {
+				i++
+			}
+		default:
+			break scan
+		}
+		i++
+	}
+	if !digits {
+		return 0, false
+	}
+	v, err := strconv.ParseFloat(sc.s[start:i], 64)
+	if err != nil {
+		return 0, false
+	}
+	sc.pos = i
+	return v, true
+}
+
+// flag reads an arc flag, which is a single 0 or 1 that may not be
+// separated from the following number.
+func (sc *svgScanner) flag() (bool, bool) {
+	sc.skipSpace()
+	if sc.pos < len(sc.s) && (sc.s[sc.pos] == '0' || sc.s[sc.pos] == '1') {
+		sc.pos++
+		return sc.s[sc.pos-1] == '1', true
+	}
+	return false, false
+}
+
+// svgPath adds the path described by the SVG path data d to the current
+// path.
+func svgPath(p *Page, d string) error {
+	sc := svgScanner{s: d}
+	var cmd byte
+	var x, y, startX, startY float64
+	// cx and cy are the last control point, for the S and T commands.
+	var cx, cy float64
+	var prevCmd byte
+
+	for {
+		sc.skipSpace()
+		if sc.pos == len(sc.s) {
+			return nil
+		}
+		if c := sc.s[sc.pos]; strings.IndexByte("MmLlHhVvCcSsQqTtAaZz", c) >= 0 {
+			cmd = c
+			sc.pos++
+		} else if cmd == 0 {
+			return fmt.Errorf("path data doesn't start with a command: %q", d)
+		} else if cmd == 'Z' || cmd == 'z' {
+			return fmt.Errorf("bad path data: %q", d)
+		}
+
+		// nums reads n numbers.
+		nums := func(n int) ([]float64, bool) {
+			v := make([]float64, n)
+			for i := range v {
+				var ok bool
+				if v[i], ok = sc.number(); !ok {
+					return nil, false
+				}
+			}
+			return v, true
+		}
+		rel := cmd >= 'a'
+		ox, oy := 0.0, 0.0
+		if rel {
+			ox, oy = x, y
+		}
+
+		switch cmd {
+		case 'M', 'm':
+			v, ok := nums(2)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			x, y = ox+v[0], oy+v[1]
+			p.MoveTo(x, y)
+			startX, startY = x, y
+			// Further coordinate pairs are implicit lineto commands.
+			if rel {
+				cmd = 'l'
+			} else {
+				cmd = 'L'
+			}
+			prevCmd = 'M'
+			continue
+		case 'L', 'l':
+			v, ok := nums(2)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			x, y = ox+v[0], oy+v[1]
+			p.LineTo(x, y)
+		case 'H', 'h':
+			v, ok := nums(1)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			x = ox + v[0]
+			p.LineTo(x, y)
+		case 'V', 'v':
+			v, ok := nums(1)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			y = oy + v[0]
+			p.LineTo(x, y)
+		case 'C', 'c':
+			v, ok := nums(6)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			p.CurveTo(ox+v[0], oy+v[1], ox+v[2], oy+v[3], ox+v[4], oy+v[5])
+			cx, cy = ox+v[2], oy+v[3]
+			x, y = ox+v[4], oy+v[5]
+		case 'S', 's':
+			v, ok := nums(4)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			x1, y1 := x, y
+			if strings.IndexByte("CcSs", prevCmd) >= 0 {
+				x1, y1 = 2*x-cx, 2*y-cy
+			}
+			p.CurveTo(x1, y1, ox+v[0], oy+v[1], ox+v[2], oy+v[3])
+			cx, cy = ox+v[0], oy+v[1]
+			x, y = ox+v[2], oy+v[3]
+		case 'Q', 'q', 'T', 't':
+			var qx, qy float64
+			var v []float64
+			var ok bool
+			if cmd == 'Q' || cmd == 'q' {
+				if v, ok = nums(4); !ok {
+					return fmt.Errorf("bad path data: %q", d)
+				}
+				qx, qy = ox+v[0], oy+v[1]
+				v = v[2:]
+			} else {
+				if v, ok = nums(2); !ok {
+					return fmt.Errorf("bad path data: %q", d)
+				}
+				qx, qy = x, y
+				if strings.IndexByte("QqTt", prevCmd) >= 0 {
+					qx, qy = 2*x-cx, 2*y-cy
+				}
+			}
+			ex, ey := ox+v[0], oy+v[1]
+			// Convert the quadratic curve to a cubic one.
+			p.CurveTo(x+2.0/3*(qx-x), y+2.0/3*(qy-y), ex+2.0/3*(qx-ex), ey+2.0/3*(qy-ey), ex, ey)
+			cx, cy = qx, qy
+			x, y = ex, ey
+		case 'A', 'a':
+			v, ok := nums(3)
+			if !ok {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			large, ok1 := sc.flag()
+			sweep, ok2 := sc.flag()
+			end, ok3 := nums(2)
+			if !ok1 || !ok2 || !ok3 {
+				return fmt.Errorf("bad path data: %q", d)
+			}
+			ex, ey := ox+end[0], oy+end[1]
+			svgArc(p, x, y, v[0], v[1], v[2], large, sweep, ex, ey)
+			x, y = ex, ey
+		case 'Z', 'z':
+			p.ClosePath()
+			x, y = startX, startY
+		}
+		prevCmd = cmd
+	}
+}
+
+// svgArc adds an SVG elliptical arc from (x1, y1) to (x2, y2) to the
+// current path, using the endpoint-to-center conversion from the SVG
+// specification.
+func svgArc(p *Page, x1, y1, rx, ry, angle float64, large, sweep bool, x2, y2 float64) {
+	if x1 == x2 && y1 == y2 {
+		return
+	}
+	rx, ry = math.Abs(rx), math.Abs(ry)
+	if rx == 0 || ry == 0 {
+		p.LineTo(x2, y2)
+		return
+	}
+	phi := angle * math.Pi / 180
+	sinPhi, cosPhi := math.Sin(phi), math.Cos(phi)
+
+	dx, dy := (x1-x2)/2, (y1-y2)/2
+	x1p := cosPhi*dx + sinPhi*dy
+	y1p := -sinPhi*dx + cosPhi*dy
+
+	// Scale up the radii if they are too small to reach.
+	if l := x1p*x1p/(rx*rx) + y1p*y1p/(ry*ry); l > 1 {
+		rx *= math.Sqrt(l)
+		ry *= math.Sqrt(l)
+	}
+
+	num := rx*rx*ry*ry - rx*rx*y1p*y1p - ry*ry*x1p*x1p
+	den := rx*rx*y1p*y1p + ry*ry*x1p*x1p
+	coef := math.Sqrt(math.Max(0, num/den))
+	if large == sweep {
+		coef = -coef
+	}
+	cxp := coef * rx * y1p / ry
+	cyp := -coef * ry * x1p / rx
+	cx := cosPhi*cxp - sinPhi*cyp + (x1+x2)/2
+	cy := sinPhi*cxp + cosPhi*cyp + (y1+y2)/2
+
+	theta1 := math.Atan2((y1p-cyp)/ry, (x1p-cxp)/rx)
+	theta2 := math.Atan2((-y1p-cyp)/ry, (-x1p-cxp)/rx)
+	dTheta := theta2 - theta1
+	if sweep && dTheta < 0 {
+		dTheta += 2 * math.Pi
+	} else if !sweep && dTheta > 0 {
+		dTheta -= 2 * math.Pi
+	}
+
+	// point returns the point on the ellipse at parameter t, and the
+	// derivative there.
+	point := func(t float64) (x, y, dx, dy float64) {
+		ex, ey := rx*math.Cos(t), ry*math.Sin(t)
+		edx, edy := -rx*math.Sin(t), ry*math.Cos(t)
+		return cx + cosPhi*ex - sinPhi*ey, cy + sinPhi*ex + cosPhi*ey,
+			cosPhi*edx - sinPhi*edy, sinPhi*edx + cosPhi*edy
+	}
+	n := math.Ceil(math.Abs(dTheta) / (math.Pi / 2))
+	step := dTheta / n
+	k := 4.0 / 3 * math.Tan(step/4)
+	for i := 0; i < int(n); i++ {
+		t0 := theta1 + float64(i)*step
+		ax, ay, adx, ady := point(t0)
+		bx, by, bdx, bdy := point(t0 + step)
+		if i == int(n)-1 {
+			bx, by = x2, y2
+		}
+		p.CurveTo(ax+k*adx, ay+k*ady, bx-k*bdx, by-k*bdy, bx, by)
+	}
+}
+
+// svgEllipse adds an ellipse to the current path.
+func svgEllipse(p *Page, cx, cy, rx, ry float64) {
+	const k = 0.5522847498
+	p.MoveTo(cx+rx, cy)
+	p.CurveTo(cx+rx, cy+k*ry, cx+k*rx, cy+ry, cx, cy+ry)
+	p.CurveTo(cx-k*rx, cy+ry, cx-rx, cy+k*ry, cx-rx, cy)
+	p.CurveTo(cx-rx, cy-k*ry, cx-k*rx, cy-ry, cx, cy-ry)
+	p.CurveTo(cx+k*rx, cy-ry, cx+rx, cy-k*ry, cx+rx, cy)
+	p.ClosePath()
+}
+
+// svgRoundedRect adds a rectangle with elliptical corners to the current
+// path.
+func svgRoundedRect(p *Page, x, y, w, h, rx, ry float64) {
+	const k = 0.5522847498
+	p.MoveTo(x+rx, y)
+	p.LineTo(x+w-rx, y)
+	p.CurveTo(x+w-rx+k*rx, y, x+w, y+ry-k*ry, x+w, y+ry)
+	p.LineTo(x+w, y+h-ry)
+	p.CurveTo(x+w, y+h-ry+k*ry, x+w-rx+k*rx, y+h, x+w-rx, y+h)
+	p.LineTo(x+rx, y+h)
+	p.CurveTo(x+rx-k*rx, y+h, x, y+h-ry+k*ry, x, y+h-ry)
+	p.LineTo(x, y+ry)
+	p.CurveTo(x, y+ry-k*ry, x+rx-k*rx, y, x+rx, y)
+	p.ClosePath()
+}
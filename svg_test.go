@@ -0,0 +1,44 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestSVGNumberAfterClosePath(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	done := make(chan error, 1)
+	go func() {
+		done <- p.DrawSVG(0, 0, 100, 100, []byte(`<svg viewBox="0 0 10 10"><path d="M0 0 L1 1 Z 5"/></svg>`))
+	}()
+	select {
+	case err := <-done:
+		if err != nil {
+			t.Fatal(err)
+		}
+	case <-time.After(5 * time.Second):
+		t.Fatal("DrawSVG didn't finish")
+	}
+	if len(p.problems) == 0 {
+		t.Error("bad path data not reported")
+	}
+}
+
+func TestSVGParseErrorRestoresState(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	err := p.DrawSVG(0, 0, 100, 100, []byte(`<svg viewBox="0 0 10 10"><g><rect width="5" height="5"`))
+	if err == nil {
+		t.Fatal("no error for truncated SVG")
+	}
+	if p.saveDepth != 0 {
+		t.Errorf("save depth is %d after error", p.saveDepth)
+	}
+	for _, err := range d.Validate() {
+		if strings.Contains(err.Error(), "Save") {
+			t.Error(err)
+		}
+	}
+}
@@ -0,0 +1,237 @@
+package pdf
+
+import (
+	"fmt"
+	"sort"
+)
+
+// EnableAutoTagging turns on a simple tagged-PDF mode for the document. In
+// this mode, the text drawn by WordWrap, WordWrapJustified, Paragraph, and
+// Heading (and so by Flow) is marked as paragraphs and headings, and a
+// structure tree listing them in the order they were drawn is generated,
+// which is enough for screen readers and text extraction to follow the
+// reading order. Running headers and footers are marked as artifacts.
+//
+// Content drawn in other ways, such as with paths, images, and the simpler
+// text methods like Left, is neither tagged nor marked as an artifact, and
+// checkers for accessible PDF (PDF/UA) reject untagged content. Decorative
+// content of this kind can be marked as an artifact by drawing it between
+// BeginMarkedContent("Artifact") and EndMarkedContent.
+//
+// EnableAutoTagging should be called before any content is drawn.
+func (d *Document) EnableAutoTagging() {
+	d.autoTag = true
+}
+
+// A structTreeRoot is the root of a document's logical structure tree.
+type structTreeRoot struct {
+	doc   *Document
+	elems []*structElem
+
+	// open is the grouping element (such as a table) that new elements are
+	// added to, or nil if they are added at the top level.
+	open *structElem
+}
+
+// A structElem is an element in the structure tree. Most elements
+// correspond to one marked-content sequence on a page; grouping elements
+// (such as tables and their rows and cells) have other elements as their
+// children instead.
+type structElem struct {
+	root   *structTreeRoot
+	parent *structElem // nil for top-level elements
+	tag    string
+
+	// attrs is the element's attribute dictionary, if any.
+	attrs string
+
+	// page is the page where the element's content is (or, for a grouping
+	// element, where it starts), and order is the page's reading order key
+	// when the element was created (see SetReadingOrder).
+	page  *Page
+	mcid  int
+	order int
+
+	kids  []*structElem
+	group bool
+}
+
+func (s *structElem) writeTo(e *encoder) {
+	parent := e.getRef(s.root)
+	if s.parent != nil {
+		parent = e.getRef(s.parent)
+	}
+	fmt.Fprintf(e, "<< /Type /StructElem /S /%s /P %d 0 R ", s.tag, parent)
+	if s.attrs != "" {
+		fmt.Fprintf(e, "/A %s ", s.attrs)
+	}
+	if s.group {
+		fmt.Fprint(e, "/K [")
+		for i, kid := range readingOrder(s.kids) {
+			if i > 0 {
+				e.WriteByte(' ')
+			}
+			fmt.Fprintf(e, "%d 0 R", e.getRef(kid))
+		}
+		fmt.Fprint(e, "] >>")
+		return
+	}
+	fmt.Fprintf(e, "/Pg %d 0 R /K %d >>", e.getRef(s.page), s.mcid)
+}
+
+// add adds elem to the structure tree, as a child of the open grouping
+// element if there is one.
+func (t *structTreeRoot) add(elem *structElem) {
+	elem.root = t
+	elem.parent = t.open
+	if t.open != nil {
+		t.open.kids = append(t.open.kids, elem)
+	} else {
+		t.elems = append(t.elems, elem)
+	}
+}
+
+func (t *structTreeRoot) writeTo(e *encoder) {
+	fmt.Fprint(e, "<< /Type /StructTreeRoot /K [")
+	for i, elem := range readingOrder(t.elems) {
+		if i > 0 {
+			e.WriteByte(' ')
+		}
+		fmt.Fprintf(e, "%d 0 R", e.getRef(elem))
+	}
+	fmt.Fprint(e, "] /ParentTree << /Nums [")
+	for i, p := range t.doc.pages.pages {
+		if len(p.structElems) == 0 {
+			continue
+		}
+		fmt.Fprintf(e, "%d [", i)
+		for j, elem := range p.structElems {
+			if j > 0 {
+				e.WriteByte(' ')
+			}
+			fmt.Fprintf(e, "%d 0 R", e.getRef(elem))
+		}
+		fmt.Fprint(e, "] ")
+	}
+	fmt.Fprint(e, "] >> >>")
+}
+
+// SetReadingOrder sets the reading order key for the content tagged on p
+// after it is called, for documents with automatic tagging (see
+// EnableAutoTagging). The structure tree, which screen readers and text
+// extraction follow, lists the content of each page in order of increasing
+// key, and content with the same key in the order it was drawn. The pages'
+// content is listed in page order. The default key is 0.
+//
+// This allows content to be drawn out of its reading order. For example,
+// in a two-column layout where the second column is drawn before the first
+// one is finished, the columns can be given keys 1 and 2; footnotes drawn
+// early can be given a high key so that they are read last.
+//
+// Without tagging, viewers generally copy and extract text in the order it
+// was drawn, so it should be drawn in reading order.
+func (p *Page) SetReadingOrder(key int) {
+	p.readingOrder = key
+}
+
+// readingOrder returns a copy of elems, sorted in reading order: by page,
+// and then by reading order key.
+func readingOrder(elems []*structElem) []*structElem {
+	// Page.Index searches the page list, so it is called once per page
+	// rather than in each comparison.
+	index := make(map[*Page]int)
+	for _, e := range elems {
+		if _, ok := index[e.page]; !ok {
+			index[e.page] = e.page.Index()
+		}
+	}
+	sorted := append([]*structElem(nil), elems...)
+	sort.SliceStable(sorted, func(i, j int) bool {
+		a, b := sorted[i], sorted[j]
+		if pa, pb := index[a.page], index[b.page]; pa != pb {
+			return pa < pb
+		}
+		return a.order < b.order
+	})
+	return sorted
+}
+
+// tagging reports whether content drawn on p should be tagged. Patterns and
+// running headers and footers are not tagged.
+func (p *Page) tagging() bool {
+	return p.doc != nil && p.doc.autoTag && p.parent != nil && !p.artifact
+}
+
+// beginTag starts a marked-content sequence for a structure element with the
+// given tag (such as P or H), if automatic tagging is enabled. Each call must
+// be matched by a call to endTag.
+func (p *Page) beginTag(tag string) {
+	if !p.tagging() {
+		return
+	}
+	root := &p.doc.structTree
+	root.doc = p.doc
+	elem := &structElem{
+		tag:   tag,
+		page:  p,
+		mcid:  len(p.structElems),
+		order: p.readingOrder,
+	}
+	root.add(elem)
+	p.structElems = append(p.structElems, elem)
+	p.contents.name(tag)
+	p.contents.dict(fmt.Sprintf("/MCID %d", elem.mcid))
+	p.contents.op("BDC")
+}
+
+// endTag ends a marked-content sequence started by beginTag.
+func (p *Page) endTag() {
+	if !p.tagging() {
+		return
+	}
+	p.contents.op("EMC")
+}
+
+// beginGroup starts a grouping structure element with the given tag and
+// attribute dictionary, if automatic tagging is enabled. The elements
+// tagged until the matching call to endGroup become its children.
+func (p *Page) beginGroup(tag, attrs string) {
+	if !p.tagging() {
+		return
+	}
+	root := &p.doc.structTree
+	root.doc = p.doc
+	elem := &structElem{tag: tag, attrs: attrs, page: p, order: p.readingOrder, group: true}
+	root.add(elem)
+	root.open = elem
+}
+
+// endGroup ends a grouping element started by beginGroup.
+func (p *Page) endGroup() {
+	if !p.tagging() {
+		return
+	}
+	root := &p.doc.structTree
+	root.open = root.open.parent
+}
+
+// beginArtifact marks the content drawn until the matching call to
+// endArtifact as an artifact, which is not part of the structure tree, if
+// automatic tagging is enabled.
+func (p *Page) beginArtifact() {
+	if !p.tagging() {
+		return
+	}
+	p.contents.name("Artifact")
+	p.contents.op("BMC")
+	p.artifact = true
+}
+
+// endArtifact ends an artifact started by beginArtifact.
+func (p *Page) endArtifact() {
+	if !p.artifact {
+		return
+	}
+	p.contents.op("EMC")
+	p.artifact = false
+}
@@ -0,0 +1,68 @@
+package pdf
+
+import "fmt"
+
+// A Template is a block of content that is drawn once and can then be
+// placed on many pages, in any number of documents: its contents are stored
+// in a single form XObject, which each document that uses it contains only
+// once. It is drawn with the methods of the embedded Page.
+//
+// Fonts from ParseFont or LoadFont, images from NewImage, and templates are
+// not tied to the document that created them, so for generating many
+// similar documents (such as a mail merge), they can be prepared once and
+// then used in each document, which needs to add only its own content. They
+// are not safe for concurrent use, however, so documents that share them
+// should be generated one at a time.
+type Template struct {
+	*Page
+}
+
+// NewTemplate returns a new Template with the given size. Its content is
+// clipped to the rectangle from (0, 0) to (width, height).
+func NewTemplate(width, height float64) *Template {
+	t := &Template{
+		Page: &Page{
+			doc:    new(Document),
+			width:  width,
+			height: height,
+			state:  initialState,
+		},
+	}
+	t.NewFragment()
+	return t
+}
+
+func (t *Template) writeTo(e *encoder) {
+	// A form XObject has only one content stream, so any fragments are
+	// joined.
+	s := &stream{content: true}
+	for _, f := range t.fragments {
+		endFragment(f)
+		s.b.Write(f.b.Bytes())
+	}
+	if len(t.redactions) > 0 {
+		data := t.redactedContent(s.b.Bytes())
+		s.b.Reset()
+		s.b.Write(data)
+	}
+	s.extraData = fmt.Sprintf("/Type /XObject /Subtype /Form /BBox [0 0 %g %g] /Resources %s", t.width, t.height, t.resources(e))
+	s.writeTo(e)
+}
+
+// DrawTemplate draws t with its lower left corner at (x, y) (or its top left
+// corner, if p is in top-down mode).
+func (p *Page) DrawTemplate(t *Template, x, y float64) {
+	id, ok := p.templates[t]
+	if !ok {
+		if p.templates == nil {
+			p.templates = make(map[*Template]int)
+		}
+		id = len(p.templates)
+		p.templates[t] = id
+	}
+	if p.topDown {
+		p.drawXObject(fmt.Sprintf("Tp%d", id), 1, 0, 0, -1, x, y+t.height)
+	} else {
+		p.drawXObject(fmt.Sprintf("Tp%d", id), 1, 0, 0, 1, x, y)
+	}
+}
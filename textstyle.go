@@ -0,0 +1,354 @@
+package pdf
+
+import (
+	"math"
+	"strings"
+)
+
+// An Alignment specifies how a line of text is positioned horizontally.
+type Alignment int
+
+const (
+	// AlignLeft puts the left end of the text at the x coordinate.
+	AlignLeft Alignment = iota
+
+	// AlignCenter centers the text on the x coordinate, or in the width
+	// available.
+	AlignCenter
+
+	// AlignRight puts the right end of the text at the x coordinate, or at
+	// the right edge of the width available.
+	AlignRight
+)
+
+// A TextStyle bundles the settings used to draw text, so that a named style
+// (such as a heading or caption style) can be defined once and applied
+// wherever it is needed.
+type TextStyle struct {
+	Font *Font
+	Size float64
+
+	// Leading is the distance between baselines of multiline text. If it
+	// is zero, the page's current leading is left unchanged.
+	Leading float64
+
+	// Color is the color used to fill the text. If it is nil, the page's
+	// current fill color is left unchanged.
+	Color Color
+
+	// CharSpacing is extra space added after each character, in points.
+	CharSpacing float64
+
+	Alignment Alignment
+}
+
+// SetCharSpacing sets the amount of extra space (in points) to be added
+// after each character of text.
+func (p *Page) SetCharSpacing(spacing float64) {
+	p.contents.num(spacing)
+	p.contents.op("Tc")
+	p.charSpacing = spacing
+}
+
+// SetTextStyle applies the font, size, leading, color, and character spacing
+// of style to the page. (The alignment is used only by the methods that take
+// a TextStyle directly.)
+func (p *Page) SetTextStyle(style TextStyle) {
+	p.SetFont(style.Font, style.Size)
+	if style.Leading != 0 {
+		p.SetLeading(style.Leading)
+	}
+	if style.Color != nil {
+		p.SetFillColor(style.Color)
+	}
+	if style.CharSpacing != 0 || p.charSpacing != 0 {
+		p.SetCharSpacing(style.CharSpacing)
+	}
+}
+
+// TextWithStyle applies style and puts s on the page at (x, y), aligned
+// according to style.Alignment.
+func (p *Page) TextWithStyle(x, y float64, s string, style TextStyle) {
+	p.SetTextStyle(style)
+	switch style.Alignment {
+	case AlignCenter:
+		p.Center(x, y, s)
+	case AlignRight:
+		p.Right(x, y, s)
+	default:
+		p.Left(x, y, s)
+	}
+}
+
+// MultilineWithStyle applies style and puts multiple lines of text on the
+// page (splitting s at '\n'), like Multiline, aligned according to
+// style.Alignment.
+func (p *Page) MultilineWithStyle(x, y float64, s string, style TextStyle) {
+	p.SetTextStyle(style)
+	p.alignedLines(x, y, 0, strings.Split(s, "\n"), style.Alignment)
+}
+
+// WordWrapWithStyle applies style and displays s on multiple lines like
+// WordWrap, with each line aligned within width according to
+// style.Alignment. (Character spacing is not taken into account when
+// choosing the line breaks.)
+func (p *Page) WordWrapWithStyle(x, y, width float64, s string, style TextStyle) {
+	p.SetTextStyle(style)
+	var lines []string
+	for _, line := range p.font().wrapLines(s, int(width/p.currentSize*1000)) {
+		lines = append(lines, strings.Join(line.words, " "))
+	}
+	p.alignedLines(x, y, width, lines, style.Alignment)
+}
+
+// alignedLines draws lines, starting at (x, y), with each line's position
+// determined by align. For AlignCenter and AlignRight, lines are centered or
+// right-aligned in a box of the given width starting at x.
+func (p *Page) alignedLines(x, y, width float64, lines []string, align Alignment) {
+	p.beginText()
+	prevX, prevY := 0.0, 0.0
+	for i, line := range lines {
+		tj, w := p.measure(line)
+		lineX := x
+		switch align {
+		case AlignCenter:
+			lineX = x + (width-w)/2
+		case AlignRight:
+			lineX = x + width - w
+		}
+		lineY := y - float64(i)*p.leading
+		p.textAt(lineX-prevX, lineY-prevY, tj)
+		prevX, prevY = lineX, lineY
+	}
+	p.endText()
+}
+
+// A TextSpan is a piece of text for RichLine, with optional styling. Fields
+// that are left at their zero values use the page's current settings.
+type TextSpan struct {
+	Text  string
+	Font  *Font
+	Size  float64
+	Color Color
+
+	Underline     bool
+	Strikethrough bool
+}
+
+// RichLine puts the spans on the page one after another, starting at (x, y),
+// each in its own font, size, and color. Underlines and strikethroughs are
+// drawn in the color of their span's text. The page's font and graphics
+// state are restored afterward. It returns the total width of the line.
+func (p *Page) RichLine(x, y float64, spans []TextSpan) (width float64) {
+	p.Save()
+
+	type decoration struct {
+		x, y, width, thickness float64
+		color                  Color
+	}
+	var decorations []decoration
+	// color is the text color set by the spans so far, or nil if it is
+	// still the page's original fill color.
+	color := p.textColor
+
+	p.beginText()
+	p.TextMove(x, y)
+	for _, span := range spans {
+		if span.Font != nil || span.Size != 0 {
+			f, s := span.Font, span.Size
+			if f == nil {
+				f = p.font()
+			}
+			if s == 0 {
+				s = p.currentSize
+			}
+			p.SetFont(f, s)
+		}
+		if span.Color != nil {
+			p.SetFillColor(span.Color)
+			color = span.Color
+		}
+		tj, w := p.measure(span.Text)
+		p.showTJ(tj)
+
+		thickness := p.currentSize * 0.06
+		if span.Underline {
+			decorations = append(decorations, decoration{x + width, y - p.currentSize*0.15, w, thickness, color})
+		}
+		if span.Strikethrough {
+			decorations = append(decorations, decoration{x + width, y + p.currentSize*0.3, w, thickness, color})
+		}
+		width += w
+	}
+	p.endText()
+	p.Restore()
+
+	// Draw the decorations in the original fill color first, since the
+	// others change the fill color.
+	for _, d := range decorations {
+		if d.color == nil {
+			p.Rectangle(d.x, d.y-d.thickness/2, d.width, d.thickness)
+			p.Fill()
+		}
+	}
+	p.Save()
+	for _, d := range decorations {
+		if d.color != nil {
+			p.SetFillColor(d.color)
+			p.Rectangle(d.x, d.y-d.thickness/2, d.width, d.thickness)
+			p.Fill()
+		}
+	}
+	p.Restore()
+	return width
+}
+
+// A VerticalAlignment specifies how a block of text is positioned
+// vertically in a box.
+type VerticalAlignment int
+
+const (
+	// AlignTop puts the top of the text at the top of the box.
+	AlignTop VerticalAlignment = iota
+
+	// AlignMiddle centers the text vertically in the box.
+	AlignMiddle
+
+	// AlignBottom puts the bottom of the text at the bottom of the box.
+	AlignBottom
+)
+
+// An Overflow specifies what TextInBox does with text that is too tall for
+// its box.
+type Overflow int
+
+const (
+	// OverflowVisible draws all the text, even the lines that extend
+	// outside the box.
+	OverflowVisible Overflow = iota
+
+	// OverflowClip draws all the text, but clips it to the box.
+	OverflowClip
+
+	// OverflowShrink reduces the font size (and leading) until the text
+	// fits in the box.
+	OverflowShrink
+)
+
+// TextInBox word-wraps s (starting a new line at each '\n') to the width of
+// box, in the current font, size, and leading, and draws it with each line
+// aligned according to hAlign and the block of lines as a whole aligned
+// according to vAlign. The height of the block is measured from the
+// ascent of the first line to the descent of the last. The page's font
+// size and leading are left unchanged, even with OverflowShrink.
+func (p *Page) TextInBox(box Rect, s string, hAlign Alignment, vAlign VerticalAlignment, overflow Overflow) {
+	size, leading := p.currentSize, p.leading
+	ascent, descent := p.font().metrics()
+	blockHeight := func(lines []string, size, leading float64) float64 {
+		return float64(ascent+descent)*0.001*size + float64(len(lines)-1)*leading
+	}
+	lines := p.boxLines(box.Width(), s, size)
+
+	p.Save()
+	switch overflow {
+	case OverflowClip:
+		p.AddRect(box)
+		p.Clip()
+	case OverflowShrink:
+		newSize, newLeading := size, leading
+		for blockHeight(lines, newSize, newLeading) > box.Height() && newSize > size/10 {
+			newSize *= 0.95
+			newLeading *= 0.95
+			lines = p.boxLines(box.Width(), s, newSize)
+		}
+		if newSize != size {
+			p.SetFont(p.currentFont, newSize)
+			p.SetLeading(newLeading)
+		}
+	}
+
+	height := blockHeight(lines, p.currentSize, p.leading)
+	var y float64
+	switch vAlign {
+	case AlignTop:
+		y = box.Y1
+	case AlignMiddle:
+		y = (box.Y0+box.Y1)/2 + height/2
+	case AlignBottom:
+		y = box.Y0 + height
+	}
+	y -= float64(ascent) * 0.001 * p.currentSize
+	p.alignedLines(box.X0, y, box.Width(), lines, hAlign)
+	p.Restore()
+}
+
+// boxLines splits s into lines for TextInBox, wrapping them to width at the
+// given font size.
+func (p *Page) boxLines(width float64, s string, size float64) []string {
+	var lines []string
+	for _, segment := range strings.Split(s, "\n") {
+		wrapped := p.font().wrapLines(segment, int(width/size*1000))
+		if len(wrapped) == 0 {
+			lines = append(lines, "")
+		}
+		for _, line := range wrapped {
+			lines = append(lines, strings.Join(line.words, " "))
+		}
+	}
+	return lines
+}
+
+// underline returns the position (of the center of the line, relative to
+// the baseline) and thickness of f's underline, in 1/1000 em.
+func (f *Font) underline() (position, thickness float64) {
+	post := f.sfnt.PostTable()
+	unitsPerEm := float64(f.sfnt.UnitsPerEm())
+	if post == nil || post.UnderlineThickness <= 0 || unitsPerEm == 0 {
+		return -150, 60
+	}
+	thickness = float64(post.UnderlineThickness) * 1000 / unitsPerEm
+	position = float64(post.UnderlinePosition)*1000/unitsPerEm - thickness/2
+	return position, thickness
+}
+
+// WavyUnderline strokes a wavy line, like the ones spelling checkers use,
+// under the text s as it would be drawn with Left at (x, y) in the current
+// font and size. It draws only the line, in the current stroke color, at
+// the font's underline position; the line width is restored afterward.
+func (p *Page) WavyUnderline(x, y float64, s string) {
+	_, width := p.measure(s)
+	if width <= 0 {
+		return
+	}
+	position, thickness := p.font().underline()
+	size := p.currentSize
+	offset := position * 0.001 * size
+	if p.topDown {
+		// y increases downward, so the line goes below the baseline by
+		// adding instead.
+		offset = -offset
+	}
+	center := y + offset
+	amplitude := size * 0.05
+	halfWave := size * 0.125
+
+	// Round to a whole number of half waves, so that the line ends where
+	// the text does.
+	n := math.Max(1, math.Round(width/halfWave))
+	halfWave = width / n
+
+	p.Save()
+	p.SetLineWidth(thickness * 0.001 * size)
+	p.MoveTo(x, center)
+	for i := 0; i < int(n); i++ {
+		x0 := x + float64(i)*halfWave
+		// A cubic curve peaks at 3/4 the height of its control points.
+		h := amplitude * 4 / 3
+		if i%2 == 1 {
+			h = -h
+		}
+		p.CurveTo(x0+halfWave/3, center+h, x0+halfWave*2/3, center+h, x0+halfWave, center)
+	}
+	p.Stroke()
+	p.Restore()
+}
@@ -0,0 +1,35 @@
+package pdf
+
+import (
+	"strconv"
+	"testing"
+)
+
+func TestWavyUnderlineTopDown(t *testing.T) {
+	f := testFont(t)
+	for _, topDown := range []bool{false, true} {
+		d := new(Document)
+		p := d.NewPage(612, 792)
+		if topDown {
+			p.SetTopDown()
+		}
+		p.SetFont(f, 12)
+		p.WavyUnderline(72, 100, "underlined")
+
+		tokens := pdfTokens(p.pageContent())
+		var y float64
+		for i, tok := range tokens {
+			if tok == "m" {
+				y, _ = strconv.ParseFloat(tokens[i-1], 64)
+				break
+			}
+		}
+		below := y < 100
+		if topDown {
+			below = y > 100
+		}
+		if !below {
+			t.Errorf("topDown = %v: line starts at y = %g, not below the baseline at 100", topDown, y)
+		}
+	}
+}
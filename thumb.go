@@ -0,0 +1,63 @@
+package pdf
+
+import (
+	"fmt"
+	"image"
+)
+
+// A RenderHook renders pages of a PDF file to images, for thumbnails. The
+// package can't rasterize PDF itself, so this is the place to plug in an
+// external rasterizer, such as a binding to a rendering library or a
+// program run as a subprocess.
+type RenderHook interface {
+	// RenderPage renders page i (counting from 0) of the PDF file doc,
+	// and returns it as an image of the size the thumbnail should be.
+	RenderPage(doc []byte, i int) (image.Image, error)
+}
+
+// SetRenderHook sets a RenderHook to make thumbnails of the document's
+// pages. When the document is encoded, it is encoded once without
+// thumbnails and passed to h for each page that doesn't have a thumbnail
+// set with SetThumbnail, and then encoded again with the images h returns
+// as the pages' thumbnails. Errors from h are reported by Validate.
+func (d *Document) SetRenderHook(h RenderHook) {
+	d.renderHook = h
+}
+
+// SetThumbnail sets a thumbnail image for the page, which viewers may show
+// in a page navigation panel. It should be in the DeviceGray or DeviceRGB
+// color space, without transparency.
+func (p *Page) SetThumbnail(img *Image) {
+	p.thumb = img
+}
+
+// renderThumbnails makes the thumbnails for the pages that need them, using
+// d's RenderHook.
+func (d *Document) renderThumbnails() {
+	for _, p := range d.pages.pages {
+		p.hookThumb, p.hookErr = nil, nil
+	}
+	if d.renderHook == nil {
+		return
+	}
+	_, b := d.encodeFile()
+	for i, p := range d.pages.pages {
+		if p.thumb != nil {
+			continue
+		}
+		img, err := d.renderHook.RenderPage(b, i)
+		if err != nil {
+			p.hookErr = fmt.Errorf("page %d: rendering thumbnail: %v", i+1, err)
+			continue
+		}
+		p.hookThumb = NewImage(img)
+	}
+}
+
+// thumbnail returns the page's thumbnail image, or nil if it has none.
+func (p *Page) thumbnail() *Image {
+	if p.thumb != nil {
+		return p.thumb
+	}
+	return p.hookThumb
+}
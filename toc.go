@@ -0,0 +1,164 @@
+package pdf
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// A TOCEntry is an item in a table of contents.
+type TOCEntry struct {
+	Title string
+
+	// Level is the entry's depth in the table of contents: 0 for top-level
+	// entries, 1 for the entries under them, and so on. Each level is
+	// indented further.
+	Level int
+
+	// Dest is where the entry links to; its page number is the one shown.
+	Dest Destination
+}
+
+// TOCEntries returns an entry for each of the document's bookmarks, in
+// outline order, with the bookmarks' nesting as their levels.
+func (d *Document) TOCEntries() []TOCEntry {
+	var entries []TOCEntry
+	var add func(list []*Bookmark, level int)
+	add = func(list []*Bookmark, level int) {
+		for _, b := range list {
+			entries = append(entries, TOCEntry{Title: b.title, Level: level, Dest: b.dest})
+			add(b.children, level+1)
+		}
+	}
+	add(d.outline.children, 0)
+	return entries
+}
+
+// GenerateTOC adds a table of contents, inserting as many pages as it needs
+// at index i (see InsertPage), and returns them. The pages are the same
+// size as the page currently at index i (or the last page, if i is the
+// number of pages). The table is laid out in the content box of each page
+// (see SetMargins) under the given title, with the entries in font at size
+// and the title somewhat larger. Each entry's title is on the left, wrapped
+// if necessary, and its page number on the right, with a row of dots
+// between them; the whole line links to the entry's destination.
+//
+// Since page numbers are taken from the positions of the destinations'
+// pages after the table of contents has been inserted, it is generated
+// after the rest of the document, typically from the headings recorded
+// while laying it out, or from the bookmarks (see TOCEntries).
+func (d *Document) GenerateTOC(i int, title string, font *Font, size float64, entries []TOCEntry) []*Page {
+	width, height := 612.0, 792.0
+	switch {
+	case i < len(d.pages.pages):
+		width, height = d.pages.pages[i].width, d.pages.pages[i].height
+	case len(d.pages.pages) > 0:
+		last := d.pages.pages[len(d.pages.pages)-1]
+		width, height = last.width, last.height
+	}
+
+	// Lay out the lines first, to find out how many pages are needed.
+	first := d.InsertPage(i, width, height)
+	leading := size * 1.5
+	titleSize := size * 1.6
+	ascent, descent := font.metrics()
+	left, bottom, boxWidth, boxHeight := first.ContentBox()
+	top := bottom + boxHeight
+
+	type tocLine struct {
+		page   int
+		y      float64
+		text   string
+		indent float64
+		entry  int
+		last   bool
+	}
+	var lines []tocLine
+	page := 0
+	// cur is the top of the space remaining on the page; each line takes
+	// up leading units, with its text centered vertically.
+	cur := top
+	if title != "" {
+		cur -= titleSize * 2
+	}
+	textHeight := float64(ascent+descent) * 0.001 * size
+	baselineOffset := (leading-textHeight)/2 + float64(descent)*0.001*size
+	numberSpace := 3 * size
+	for n, entry := range entries {
+		indent := float64(entry.Level) * 1.5 * size
+		wrapped := font.WrapLines(entry.Title, boxWidth-indent-numberSpace, size)
+		if len(wrapped) == 0 {
+			wrapped = []WrappedLine{{}}
+		}
+		// Keep an entry's lines together, unless the entry is too long for
+		// a page.
+		if cur-float64(len(wrapped))*leading < bottom && cur < top {
+			page++
+			cur = top
+		}
+		for j, w := range wrapped {
+			if cur-leading < bottom && cur < top {
+				page++
+				cur = top
+			}
+			cur -= leading
+			lineIndent := indent
+			if j > 0 {
+				lineIndent += size
+			}
+			lines = append(lines, tocLine{page, cur + baselineOffset, w.Text, lineIndent, n, j == len(wrapped)-1})
+		}
+	}
+
+	pages := []*Page{first}
+	for len(pages) <= page {
+		pages = append(pages, d.InsertPage(i+len(pages), width, height))
+	}
+
+	if title != "" {
+		first.SetFont(font, titleSize)
+		first.Left(left, top-float64(ascent)*0.001*titleSize, title)
+	}
+	for _, p := range pages {
+		p.SetFont(font, size)
+	}
+	_, dotWidth := first.measure(".")
+	_, pitch := first.measure(". ")
+
+	for _, line := range lines {
+		p := pages[line.page]
+		x, _, _, _ := p.ContentBox()
+		right := x + boxWidth
+		entry := entries[line.entry]
+		p.Left(x+line.indent, line.y, line.text)
+		onPage := d.hasPage(entry.Dest.Page)
+
+		if line.last {
+			var number string
+			if onPage {
+				number = strconv.Itoa(entry.Dest.Page.Index() + 1)
+			} else {
+				p.problems = append(p.problems, fmt.Sprintf("table of contents entry %q links to a page that isn't in the document", entry.Title))
+			}
+
+			// The dots are placed at multiples of pitch from the left
+			// edge, so that they line up from one line to the next.
+			_, textWidth := p.measure(line.text)
+			_, numberWidth := p.measure(number)
+			start := math.Ceil((line.indent+textWidth+size/2)/pitch) * pitch
+			count := math.Floor((boxWidth-numberWidth-size/2-dotWidth-start)/pitch) + 1
+			if count > 0 {
+				p.SetCharSpacing(pitch - dotWidth)
+				p.Left(x+start, line.y, strings.Repeat(".", int(count)))
+				p.SetCharSpacing(0)
+			}
+			p.Right(right, line.y, number)
+		}
+
+		if onPage {
+			p.LinkTo(RectCorners(x+line.indent, line.y-float64(descent)*0.001*size, right, line.y+float64(ascent)*0.001*size), entry.Dest)
+		}
+	}
+	return pages
+}
@@ -0,0 +1,48 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestGenerateTOC(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	d.SetMargins(Margins{Top: 72, Bottom: 72, Inner: 72, Outer: 72})
+	var chapters []*Page
+	for i := 0; i < 3; i++ {
+		p := d.NewPage(612, 792)
+		p.SetFont(f, 12)
+		p.Left(72, 700, "Body text")
+		chapters = append(chapters, p)
+	}
+	d.AddBookmark("Introduction", Destination{Page: chapters[0], Mode: Fit})
+	d.AddBookmark("Methods", Destination{Page: chapters[1], Mode: Fit}).AddChild("Details", Destination{Page: chapters[2], Mode: Fit})
+
+	// No default font is set; GenerateTOC uses the one it is given.
+	pages := d.GenerateTOC(0, "Contents", f, 12, d.TOCEntries())
+	if len(pages) != 1 || pages[0].Index() != 0 {
+		t.Fatalf("table of contents is %d pages, starting at index %d", len(pages), pages[0].Index())
+	}
+	if errs := d.Validate(); len(errs) != 0 {
+		t.Errorf("Validate returned %v", errs)
+	}
+
+	toc := strings.Split(extracted(t, d), "\f")[0]
+	for _, want := range []string{"Contents", "Introduction", "Methods", "Details"} {
+		if !strings.Contains(toc, want) {
+			t.Errorf("table of contents %q doesn't contain %q", toc, want)
+		}
+	}
+	// The chapters have moved down a page to make room.
+	lines := strings.Split(toc, "\n")
+	for i, want := range []string{"2", "3", "4"} {
+		line := lines[len(lines)-3+i]
+		if !strings.HasSuffix(line, " "+want) || !strings.Contains(line, "...") {
+			t.Errorf("line %q should end with dot leaders and page %s", line, want)
+		}
+	}
+	if n := len(pages[0].annots); n != 3 {
+		t.Errorf("got %d links, want 3", n)
+	}
+}
@@ -0,0 +1,46 @@
+package pdf
+
+import "fmt"
+
+// The transition styles for SetTransition.
+const (
+	TransitionSplit    = "Split"
+	TransitionBlinds   = "Blinds"
+	TransitionBox      = "Box"
+	TransitionWipe     = "Wipe"
+	TransitionDissolve = "Dissolve"
+	TransitionGlitter  = "Glitter"
+	TransitionReplace  = "R"
+	TransitionFly      = "Fly"
+	TransitionPush     = "Push"
+	TransitionCover    = "Cover"
+	TransitionUncover  = "Uncover"
+	TransitionFade     = "Fade"
+)
+
+// SetTransition sets the transition effect used when a viewer in
+// presentation mode moves to this page, and how long it takes (in seconds).
+// The style should be one of the Transition constants, such as
+// TransitionWipe or TransitionFade. Any other style is ignored, and
+// reported by Document.Validate.
+func (p *Page) SetTransition(style string, duration float64) {
+	switch style {
+	case TransitionSplit, TransitionBlinds, TransitionBox, TransitionWipe, TransitionDissolve, TransitionGlitter,
+		TransitionReplace, TransitionFly, TransitionPush, TransitionCover, TransitionUncover, TransitionFade:
+		p.transition = fmt.Sprintf("<< /Type /Trans /S /%s /D %s >>", style, formatNum(duration))
+	default:
+		p.problems = append(p.problems, fmt.Sprintf("unknown transition style %q", style))
+	}
+}
+
+// SetDisplayDuration sets how long (in seconds) a viewer in presentation
+// mode displays the page before advancing to the next one automatically.
+func (p *Page) SetDisplayDuration(seconds float64) {
+	p.displayDuration = seconds
+}
+
+// SetFullScreen makes the document open in full-screen mode, with no menu
+// bar or other window controls, as for a presentation.
+func (d *Document) SetFullScreen(on bool) {
+	d.fullScreen = on
+}
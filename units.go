@@ -0,0 +1,26 @@
+package pdf
+
+// All coordinates and sizes in this package are in points (1/72 inch),
+// unless Page.SetUserUnit has been used. These functions convert other
+// units to points.
+
+// MM converts millimeters to points.
+func MM(mm float64) float64 {
+	return mm * 72 / 25.4
+}
+
+// CM converts centimeters to points.
+func CM(cm float64) float64 {
+	return cm * 72 / 2.54
+}
+
+// Inch converts inches to points.
+func Inch(in float64) float64 {
+	return in * 72
+}
+
+// Px converts a distance in pixels, at a resolution of dpi pixels per inch,
+// to points.
+func Px(px, dpi float64) float64 {
+	return px * 72 / dpi
+}
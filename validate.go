@@ -0,0 +1,135 @@
+package pdf
+
+import (
+	"fmt"
+
+	"golang.org/x/image/font/sfnt"
+)
+
+// maxPageSize is the largest page dimension allowed by the PDF specification.
+const maxPageSize = 14400
+
+// Validate checks the document for problems that would make it render
+// incorrectly, such as text in characters the font doesn't have, Save
+// calls without a matching Restore, and links, bookmarks, or named
+// destinations that go to pages that aren't in the document. It returns a
+// list of the problems found.
+func (d *Document) Validate() []error {
+	var errs []error
+	checkedFonts := make(map[*Font]bool)
+
+	for i, p := range d.pages.pages {
+		pageNum := i + 1
+		if p.width <= 0 || p.height <= 0 {
+			errs = append(errs, fmt.Errorf("page %d: invalid size %gx%g", pageNum, p.width, p.height))
+		}
+		if p.width > maxPageSize || p.height > maxPageSize {
+			errs = append(errs, fmt.Errorf("page %d: size %gx%g is larger than the maximum of %d", pageNum, p.width, p.height, maxPageSize))
+		}
+		if p.saveDepth != 0 || p.unbalanced {
+			errs = append(errs, fmt.Errorf("page %d: unbalanced Save and Restore", pageNum))
+		}
+		if p.markDepth != 0 {
+			errs = append(errs, fmt.Errorf("page %d: unterminated marked-content sequence", pageNum))
+		}
+		for _, problem := range p.problems {
+			errs = append(errs, fmt.Errorf("page %d: %s", pageNum, problem))
+		}
+		if p.hookErr != nil {
+			errs = append(errs, p.hookErr)
+		}
+		for _, a := range p.annots {
+			if !d.hasPage(a.dest.Page) {
+				errs = append(errs, fmt.Errorf("page %d: link to a page that isn't in the document", pageNum))
+			}
+		}
+
+		// Check the fonts in resource order, so that the errors are in
+		// the same order every time.
+		fonts := make([]*Font, len(p.fonts))
+		for f, i := range p.fonts {
+			fonts[i] = f
+		}
+		for _, f := range fonts {
+			if checkedFonts[f] {
+				continue
+			}
+			checkedFonts[f] = true
+			errs = append(errs, f.validate()...)
+		}
+	}
+
+	var checkBookmarks func(list []*Bookmark)
+	checkBookmarks = func(list []*Bookmark) {
+		for _, b := range list {
+			if !d.hasPage(b.dest.Page) {
+				errs = append(errs, fmt.Errorf("bookmark %q goes to a page that isn't in the document", b.title))
+			}
+			checkBookmarks(b.children)
+		}
+	}
+	checkBookmarks(d.outline.children)
+	for _, name := range d.destNames() {
+		if !d.hasPage(d.dests[name].Page) {
+			errs = append(errs, fmt.Errorf("named destination %q is on a page that isn't in the document", name))
+		}
+	}
+	if d.openAction != nil && !d.hasPage(d.openAction.Page) {
+		errs = append(errs, fmt.Errorf("open action goes to a page that isn't in the document"))
+	}
+
+	for i, o := range d.rawObjects {
+		if o.reserved {
+			errs = append(errs, fmt.Errorf("raw object %d was reserved but never defined with DefineObject", i+1))
+		}
+	}
+
+	known := false
+	for _, v := range pdfVersions {
+		if d.pdfVersion() == v {
+			known = true
+			break
+		}
+	}
+	if !known {
+		errs = append(errs, fmt.Errorf("unknown PDF version %q", d.pdfVersion()))
+	}
+	if len(d.dests) > 0 && d.destsFormInUse() != DestsDictionary && d.versionBefore("1.2") {
+		errs = append(errs, fmt.Errorf("named destinations are in a name tree, which requires PDF 1.2, but the document declares PDF %s", d.pdfVersion()))
+	}
+
+	return errs
+}
+
+// hasPage reports whether p is one of d's pages.
+func (d *Document) hasPage(p *Page) bool {
+	return p != nil && p.parent == &d.pages && p.Index() >= 0
+}
+
+// validate checks for characters that could not be encoded or that have no
+// glyph in the font.
+func (f *Font) validate() []error {
+	var errs []error
+	var buffer sfnt.Buffer
+	name, err := f.sfnt.Name(&buffer, sfnt.NameIDFull)
+	if err != nil {
+		name = "(unnamed)"
+	}
+
+	if len(f.dropped.list) > 0 {
+		errs = append(errs, fmt.Errorf("font %s: too many different characters; %q could not be displayed", name, string(f.dropped.list)))
+	}
+	if len(f.missing.list) > 0 {
+		errs = append(errs, fmt.Errorf("font %s: no glyphs for %q", name, string(f.missing.list)))
+	}
+	for _, r := range f.toUnicode {
+		if r == 0 {
+			continue
+		}
+		if g, err := f.sfnt.GlyphIndex(&buffer, r); err != nil || g == 0 {
+			errs = append(errs, fmt.Errorf("font %s: no glyph for %q (%U)", name, r, r))
+		}
+	}
+
+	return errs
+}
@@ -0,0 +1,112 @@
+package pdf
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestValidateDestinations(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	other := new(Document).NewPage(612, 792)
+
+	p.LinkTo(Rect{72, 700, 200, 720}, Destination{Page: p, Mode: Fit})
+	p.LinkTo(Rect{72, 600, 200, 620}, Destination{Page: other, Mode: Fit})
+	d.AddBookmark("Good", Destination{Page: p, Mode: Fit}).AddChild("Bad child", Destination{Page: other, Mode: Fit})
+	d.AddBookmark("No page", Destination{Mode: Fit})
+	d.AddDestination("good", p, 700)
+	d.AddDestination("dangling", other, 700)
+	d.SetOpenAction(Destination{Page: other, Mode: Fit})
+
+	var got []string
+	for _, err := range d.Validate() {
+		got = append(got, err.Error())
+	}
+	want := []string{
+		"page 1: link to a page that isn't in the document",
+		`bookmark "Bad child" goes to a page that isn't in the document`,
+		`bookmark "No page" goes to a page that isn't in the document`,
+		`named destination "dangling" is on a page that isn't in the document`,
+		"open action goes to a page that isn't in the document",
+	}
+	if strings.Join(got, "\n") != strings.Join(want, "\n") {
+		t.Errorf("got errors:\n%s\nwant:\n%s", strings.Join(got, "\n"), strings.Join(want, "\n"))
+	}
+}
+
+func TestValidateDroppedCharacters(t *testing.T) {
+	f := testFont(t)
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.SetFont(f, 12)
+	// There are more than 255 different characters here, so the last ones
+	// can't be encoded.
+	var b strings.Builder
+	for r := rune(0x100); r < 0x250; r++ {
+		b.WriteRune(r)
+	}
+	p.Left(72, 700, b.String())
+	n := len(f.dropped.list)
+	if n == 0 {
+		t.Fatal("no characters dropped")
+	}
+	for i := 0; i < 10; i++ {
+		p.Left(72, 700, b.String())
+	}
+	if len(f.dropped.list) != n {
+		t.Errorf("%d dropped characters recorded as %d", n, len(f.dropped.list))
+	}
+	errs := d.Validate()
+	count := 0
+	for _, err := range errs {
+		if strings.Contains(err.Error(), "too many different characters") {
+			count++
+		}
+	}
+	if count != 1 {
+		t.Errorf("Validate returned %v", errs)
+	}
+}
+
+func TestValidatePDFVersion(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	d.AddDestination("top", p, 792)
+	d.SetPDFVersion("1.1")
+
+	out := string(d.Encode())
+	if !strings.HasPrefix(out, "%PDF-1.1\n") {
+		t.Errorf("header is %q, want %%PDF-1.1", out[:strings.Index(out, "\n")])
+	}
+	if strings.Contains(out, "/Names") {
+		t.Error("PDF 1.1 file uses a name tree for its destinations")
+	}
+	if errs := d.Validate(); len(errs) != 0 {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+
+	d.SetDestsForm(DestsNameTree)
+	want := "named destinations are in a name tree, which requires PDF 1.2, but the document declares PDF 1.1"
+	if errs := d.Validate(); len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("got errors %v, want %q", errs, want)
+	}
+
+	d.SetPDFVersion("1.9")
+	want = `unknown PDF version "1.9"`
+	if errs := d.Validate(); len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("got errors %v, want %q", errs, want)
+	}
+}
+
+func TestValidateStarWithNoPoints(t *testing.T) {
+	d := new(Document)
+	p := d.NewPage(612, 792)
+	p.Star(100, 100, 50, 20, 0)
+	if content := strings.TrimSpace(string(p.pageContent())); content != "" {
+		t.Errorf("Star with no points wrote %q", content)
+	}
+	want := "page 1: Star called with 0 points"
+	if errs := d.Validate(); len(errs) != 1 || errs[0].Error() != want {
+		t.Errorf("got errors %v, want %q", errs, want)
+	}
+}